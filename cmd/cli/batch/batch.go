@@ -0,0 +1,117 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateExt is the extension of the templates rendered from a directory
+const templateExt = ".tmpl"
+
+// findTemplates lists the *.tmpl files in dir, sorted by name
+func findTemplates(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// render parses and executes the template at path
+func render(path string, replacer template.Replacer) (string, error) {
+	tmpl, err := template.ParseFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.ExecuteTo(&b, replacer); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// outputPath names the file a template renders to in outDir: notes.md.tmpl becomes notes.md
+func outputPath(outDir, path string) string {
+	return filepath.Join(outDir, strings.TrimSuffix(filepath.Base(path), templateExt))
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <dir>",
+	Short: "Render every template of a directory",
+	Long: `Render every *.tmpl file of a directory with the same replacements.
+
+The rendered templates are printed one after another, separated by a blank line,
+or written to --out-dir with the template extension dropped (notes.md.tmpl becomes notes.md).
+A template that fails to render is reported and the others are still rendered.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		paths, err := findTemplates(args[0])
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no %s templates in %s", templateExt, args[0])
+		}
+
+		outDir := viper.GetString("out-dir")
+		if outDir != "" {
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+		}
+
+		replacer := template.ReplacerFuncFromMap(viper.GetStringMapString("replacements"))
+
+		var failed int
+		var rendered []string
+		for _, path := range paths {
+			message, err := render(path, replacer)
+			if err == nil && outDir != "" {
+				err = os.WriteFile(outputPath(outDir, path), []byte(message), 0o644)
+			}
+			if err != nil {
+				failed++
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", path, err)
+				continue
+			}
+			rendered = append(rendered, message)
+		}
+
+		if outDir == "" && len(rendered) > 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), strings.Join(rendered, "\n\n"))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to render %d of %d template(s)", failed, len(paths))
+		}
+		return nil
+	},
+}
+
+// GetCommand returns the batch command
+func GetCommand() *cobra.Command {
+	return batchCmd
+}
+
+func init() {
+	batchCmd.Flags().String("out-dir", "", "Write each rendered template to a file in this directory")
+
+	if err := viper.BindPFlag("out-dir", batchCmd.Flags().Lookup("out-dir")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTemplates creates the named templates in a new directory
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range templates {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644))
+	}
+	return dir
+}
+
+// runBatch executes the batch command with the given replacements
+func runBatch(t *testing.T, replacements map[string]string, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	viper.Set("replacements", replacements)
+	t.Cleanup(func() {
+		viper.Set("replacements", nil)
+		require.NoError(t, batchCmd.Flags().Set("out-dir", ""))
+	})
+
+	var out, errOut bytes.Buffer
+	cmd := GetCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	cmd.SetArgs(args)
+	err = cmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestBatchOutDir(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"notes.md.tmpl": "# {{.version}}",
+		"tag.tmpl":      "release {{.version}} by {{.author:@ci}}",
+		"readme.txt":    "not a template",
+	})
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	_, _, err := runBatch(t, map[string]string{"version": "v1.2.0"}, dir, "--out-dir", outDir)
+	require.NoError(t, err)
+
+	notes, err := os.ReadFile(filepath.Join(outDir, "notes.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# v1.2.0", string(notes))
+
+	tag, err := os.ReadFile(filepath.Join(outDir, "tag"))
+	require.NoError(t, err)
+	assert.Equal(t, "release v1.2.0 by ci", string(tag))
+
+	entries, err := os.ReadDir(outDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestBatchConcatenatesAndReportsErrors(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"a.tmpl": "first {{.version}}",
+		"b.tmpl": "{{.missing}}",
+		"c.tmpl": "third {{.version}}",
+	})
+
+	out, errOut, err := runBatch(t, map[string]string{"version": "v1"}, dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to render 1 of 3 template(s)")
+	assert.Contains(t, errOut, "b.tmpl")
+	assert.Equal(t, "first v1\n\nthird v1\n", out)
+}
+
+func TestBatchEmptyDir(t *testing.T) {
+	_, _, err := runBatch(t, nil, t.TempDir())
+	assert.ErrorContains(t, err, "no .tmpl templates")
+}
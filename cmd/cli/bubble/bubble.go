@@ -1,49 +1,141 @@
 package bubble
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
 
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
 	"github.com/WhiCu/TCommit/internal/cli/bubble"
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/history"
 	"github.com/WhiCu/TCommit/internal/core/template"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// ErrNotTerminal is returned when the editor is started without a terminal to draw on
+var ErrNotTerminal = errors.New("bubble needs an interactive terminal; render the template with the plain command instead, e.g. tcommit <template> -r key=value")
+
+// isTerminal reports whether the stream is attached to a terminal.
+// Streams that are not files, such as buffers fed by tests, are driven directly.
+func isTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	if !ok {
+		return true
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// checkTerminal fails with ErrNotTerminal unless in and out are both usable by the editor
+func checkTerminal(in io.Reader, out io.Writer) error {
+	if !isTerminal(in) || !isTerminal(out) {
+		return ErrNotTerminal
+	}
+	return nil
+}
+
 var bubbleCmd = &cobra.Command{
 	Use:   "bubble",
 	Short: "Start interactive commit message editor",
 	Long: `Start an interactive TUI editor for creating commit messages.
-This mode allows you to fill in template variables interactively.`,
-	Args: cobra.ExactArgs(1),
+This mode allows you to fill in template variables interactively.
+Typing into a field with choices narrows them to the matching ones;
+up and down pick a match and enter selects it.
+A variable standing alone in a paragraph, such as the body, is edited
+over several lines; enter starts a new line there.
+
+Inside a git repository changed files are listed first so they can be staged
+with space before editing the message; use --no-stage to skip that screen.
+
+Colors of the input, border and title can be set in the config file,
+as hex codes or ANSI color numbers; NO_COLOR disables them:
+
+	colors:
+	  input: "#00FF00"
+	  border: "8"
+	  title: "12"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// The config file may name a default template
+		if viper.GetString("template") != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completion.SingleTemplate,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Open and parse template file
-		file, err := os.Open(args[0])
-		if err != nil {
-			return fmt.Errorf("failed to open template file: %w", err)
+		if err := checkTerminal(cmd.InOrStdin(), cmd.OutOrStdout()); err != nil {
+			return err
 		}
-		defer file.Close()
 
-		tmpl, err := template.Parse(file)
+		path := viper.GetString("template")
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		// Parse template file
+		tmpl, err := template.ParseFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to parse template: %w", err)
 		}
 
-		fileName := filepath.Base(args[0])
+		fileName := filepath.Base(path)
 
 		replace := map[string]string{}
+
+		var state history.State
+		var statePath string
+		if viper.GetBool("remember") {
+			state, statePath, err = history.Open(viper.GetString("state-file"))
+			if err != nil {
+				return fmt.Errorf("failed to load remembered values: %w", err)
+			}
+			for k, v := range state.Values(path) {
+				replace[k] = v
+			}
+		}
+
 		// Create and run the program
-		program := bubble.NewProgram(fileName, tmpl, replace)
-		if _, err := program.Run(); err != nil {
+		var runner git.Runner
+		if !viper.GetBool("no-stage") {
+			runner = git.ExecRunner{
+				Dir: viper.GetString("repo"),
+				Bin: viper.GetString("git-bin"),
+			}
+		}
+
+		program, err := bubble.NewProgram(fileName, tmpl, replace, viper.GetStringMapStringSlice("keys"), viper.GetStringMapString("colors"), runner,
+			tea.WithInput(cmd.InOrStdin()), tea.WithOutput(cmd.OutOrStdout()))
+		if err != nil {
+			return fmt.Errorf("invalid editor config: %w", err)
+		}
+		final, err := program.Run()
+		if err != nil {
 			return fmt.Errorf("program error: %w", err)
 		}
+		// Quitting leaves nothing to render, remember or commit
+		if !bubble.Submitted(final) {
+			viper.Set("canceled", true)
+			return nil
+		}
 
 		message, err := tmpl.Execute(template.ReplacerFuncFromMap(replace))
 		if err != nil {
 			return fmt.Errorf("failed to execute template: %w", err)
 		}
 
+		if state != nil {
+			state.Remember(path, editedValues(tmpl, replace))
+			if err := state.Save(statePath); err != nil {
+				return fmt.Errorf("failed to remember values: %w", err)
+			}
+		}
+
 		if viper.GetBool("execute") {
 			viper.Set("message", message)
 		}
@@ -52,7 +144,29 @@ This mode allows you to fill in template variables interactively.`,
 	},
 }
 
+// editedValues returns the values of replace the editor fields do not merely
+// prefill with the template default of their variable, so that later changes
+// to the default are not overridden by a remembered copy of it
+func editedValues(tmpl *template.Template, replace map[string]string) map[string]string {
+	edited := maps.Clone(replace)
+	for _, v := range tmpl.Variables() {
+		if v.HasDef && edited[v.Key] == v.Default {
+			delete(edited, v.Key)
+		}
+	}
+	return edited
+}
+
 // GetCommand returns the interactive command
 func GetCommand() *cobra.Command {
 	return bubbleCmd
 }
+
+func init() {
+	bubbleCmd.Flags().Bool("no-stage", false, "Skip the file staging screen")
+
+	if err := viper.BindPFlag("no-stage", bubbleCmd.Flags().Lookup("no-stage")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+package bubble
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBubbleWithoutTerminal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.txt")
+	require.NoError(t, os.WriteFile(path, []byte("{{.type}}: {{.desc}}"), 0o644))
+
+	stdin, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	t.Cleanup(func() { stdin.Close() })
+
+	root := &cobra.Command{Use: "tcommit"}
+	root.AddCommand(GetCommand())
+	root.SetIn(stdin)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"bubble", path, "--no-stage"})
+	t.Cleanup(func() {
+		root.SetIn(nil)
+		root.SetArgs(nil)
+	})
+
+	assert.ErrorIs(t, root.Execute(), ErrNotTerminal)
+}
+
+func TestCheckTerminal(t *testing.T) {
+	// Buffers are driven directly, as in tests
+	assert.NoError(t, checkTerminal(&bytes.Buffer{}, &bytes.Buffer{}))
+
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer devNull.Close()
+	assert.ErrorIs(t, checkTerminal(devNull, &bytes.Buffer{}), ErrNotTerminal)
+	assert.ErrorIs(t, checkTerminal(&bytes.Buffer{}, devNull), ErrNotTerminal)
+}
+
+func TestBubbleDirectory(t *testing.T) {
+	root := &cobra.Command{Use: "tcommit"}
+	root.AddCommand(GetCommand())
+	root.SetIn(&bytes.Buffer{})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"bubble", t.TempDir(), "--no-stage"})
+	t.Cleanup(func() {
+		root.SetIn(nil)
+		root.SetArgs(nil)
+	})
+
+	err := root.Execute()
+	require.ErrorIs(t, err, template.ErrIsDirectory)
+	assert.ErrorContains(t, err, "expected a file, got a directory")
+}
+
+func TestEditedValues(t *testing.T) {
+	tmpl, err := template.ParseString("{{.type:feat|fix|@feat}}({{.scope:@core}}): {{.desc}}")
+	require.NoError(t, err)
+
+	// Fields left at their template default are not remembered
+	edited := editedValues(tmpl, map[string]string{"type": "fix", "scope": "core", "desc": "add login"})
+	assert.Equal(t, map[string]string{"type": "fix", "desc": "add login"}, edited)
+}
@@ -0,0 +1,121 @@
+package check
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of a failed check
+const (
+	ExitMissing    = 5
+	ExitUnexpected = 6
+	ExitBoth       = 7
+)
+
+// checkError reports the keys a template does not match the required list by
+type checkError struct {
+	// Missing are required keys the template does not use
+	Missing []string
+	// Unexpected are keys the template needs a value for that are not required
+	Unexpected []string
+}
+
+func (e *checkError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required keys: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, "unexpected required keys: "+strings.Join(e.Unexpected, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ExitStatus tells the kind of mismatch apart for scripts
+func (e *checkError) ExitStatus() int {
+	switch {
+	case len(e.Missing) > 0 && len(e.Unexpected) > 0:
+		return ExitBoth
+	case len(e.Missing) > 0:
+		return ExitMissing
+	}
+	return ExitUnexpected
+}
+
+// checkKeys compares the variables of tmpl with the required keys.
+// A key needs a value when none of its uses has a default or is optional.
+func checkKeys(tmpl *template.Template, required []string) error {
+	used := make(map[string]bool)
+	var keys []string
+	for _, v := range tmpl.Variables() {
+		needsValue, ok := used[v.Key]
+		if !ok {
+			keys = append(keys, v.Key)
+			needsValue = true
+		}
+		used[v.Key] = needsValue && !v.HasDef && !v.Optional
+	}
+
+	e := &checkError{}
+	for _, key := range required {
+		if _, ok := used[key]; !ok {
+			e.Missing = append(e.Missing, key)
+		}
+	}
+	for _, key := range keys {
+		if used[key] && !slices.Contains(required, key) {
+			e.Unexpected = append(e.Unexpected, key)
+		}
+	}
+
+	if len(e.Missing) > 0 || len(e.Unexpected) > 0 {
+		return e
+	}
+	return nil
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <template> --require key1,key2",
+	Short: "Check that a template uses exactly the required keys",
+	Long: `Parse a template and check its variables against the keys a pipeline provides.
+Every key given with --require must be used by the template, and every variable
+without a default that is not optional must be among them.
+
+Exit codes: 1 when the template cannot be read, 2 when it cannot be parsed,
+5 when required keys are missing, 6 when the template needs unexpected keys
+and 7 for both.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completion.SingleTemplate,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		required, err := cmd.Flags().GetStringSlice("require")
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := template.ParseFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+
+		if err := checkKeys(tmpl, required); err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// GetCommand returns the check command
+func GetCommand() *cobra.Command {
+	return checkCmd
+}
+
+func init() {
+	checkCmd.Flags().StringSlice("require", []string{}, "Keys the template must use, comma separated")
+}
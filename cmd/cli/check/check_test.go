@@ -0,0 +1,70 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKeys(t *testing.T) {
+	tmpl, err := template.ParseString("{{.type:feat|@fix}}({{.scope?}}): {{.desc}} {{.ticket}} {{.ticket:@none}}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		required   []string
+		missing    []string
+		unexpected []string
+		code       int
+	}{
+		{name: "Matching", required: []string{"desc"}},
+		{name: "Provided keys with defaults", required: []string{"desc", "type", "scope"}},
+		{name: "Missing required", required: []string{"desc", "body"}, missing: []string{"body"}, code: ExitMissing},
+		{name: "Extra required", required: nil, unexpected: []string{"desc"}, code: ExitUnexpected},
+		{name: "Both", required: []string{"body"}, missing: []string{"body"}, unexpected: []string{"desc"}, code: ExitBoth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKeys(tmpl, tt.required)
+			if tt.code == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var checkErr *checkError
+			require.ErrorAs(t, err, &checkErr)
+			assert.Equal(t, tt.missing, checkErr.Missing)
+			assert.Equal(t, tt.unexpected, checkErr.Unexpected)
+			assert.Equal(t, tt.code, checkErr.ExitStatus())
+		})
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{.type:@feat}}: {{.desc}}"), 0o644))
+
+	cmd := GetCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	requireFlag := cmd.Flags().Lookup("require").Value.(interface{ Replace([]string) error })
+	t.Cleanup(func() { require.NoError(t, requireFlag.Replace(nil)) })
+
+	cmd.SetArgs([]string{path, "--require", "desc"})
+	require.NoError(t, cmd.Execute())
+	require.NoError(t, requireFlag.Replace(nil))
+
+	cmd.SetArgs([]string{path, "--require", "desc,body"})
+	err := cmd.Execute()
+	var coder interface{ ExitStatus() int }
+	require.True(t, errors.As(err, &coder))
+	assert.Equal(t, ExitMissing, coder.ExitStatus())
+	assert.ErrorContains(t, err, "missing required keys: body")
+}
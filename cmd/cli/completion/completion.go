@@ -0,0 +1,122 @@
+package completion
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// templateExtensions are the file extensions offered when completing a template argument
+var templateExtensions = []string{".tmpl", ".tpl", ".tcommit"}
+
+// isTemplateFile reports whether name looks like a template file
+func isTemplateFile(name string) bool {
+	if name == ".tcommit" {
+		return true
+	}
+	ext := filepath.Ext(name)
+	for _, e := range templateExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// templateFiles lists the template files in dir whose names start with prefix.
+// Subdirectories are offered too so completion can descend into them.
+func templateFiles(dir, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if entry.IsDir() {
+			files = append(files, name+string(filepath.Separator))
+		} else if isTemplateFile(name) {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// TemplateFiles completes a template path argument with the template files
+// of the directory being typed, falling back to regular file completion
+// when none match
+func TemplateFiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, prefix := filepath.Split(toComplete)
+	searchDir := dir
+	if searchDir == "" {
+		searchDir = "."
+	}
+
+	names, err := templateFiles(searchDir, prefix)
+	if err != nil || len(names) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	directive := cobra.ShellCompDirectiveNoFileComp
+	completions := make([]string, len(names))
+	for i, name := range names {
+		completions[i] = dir + name
+		if strings.HasSuffix(name, string(filepath.Separator)) {
+			// Keep typing into the directory instead of ending the argument
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+	}
+	return completions, directive
+}
+
+// SingleTemplate completes the template argument of commands taking exactly one template
+func SingleTemplate(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return TemplateFiles(cmd, args, toComplete)
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the completion script for the given shell.
+Template arguments complete to the template files of the current directory.
+
+Examples:
+	source <(tcommit completion bash)
+	tcommit completion zsh > "${fpath[1]}/_tcommit"
+	tcommit completion fish > ~/.config/fish/completions/tcommit.fish
+	tcommit completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := cmd.Root()
+		out := cmd.OutOrStdout()
+
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletionV2(out, true)
+		case "zsh":
+			return root.GenZshCompletion(out)
+		case "fish":
+			return root.GenFishCompletion(out, true)
+		case "powershell":
+			return root.GenPowerShellCompletionWithDesc(out)
+		}
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	},
+}
+
+// GetCommand returns the completion command
+func GetCommand() *cobra.Command {
+	return completionCmd
+}
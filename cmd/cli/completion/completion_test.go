@@ -0,0 +1,54 @@
+package completion
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionShells(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			root := &cobra.Command{Use: "tcommit"}
+			root.AddCommand(GetCommand())
+
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"completion", shell})
+
+			require.NoError(t, root.Execute())
+			assert.NotEmpty(t, out.String())
+		})
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	root := &cobra.Command{Use: "tcommit"}
+	root.AddCommand(GetCommand())
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"completion", "tcsh"})
+
+	assert.Error(t, root.Execute())
+}
+
+func TestTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"feat.tmpl", "fix.tpl", "notes.txt", ".tcommit", "main.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "templates"), 0o755))
+
+	files, err := templateFiles(dir, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".tcommit", "feat.tmpl", "fix.tpl", "templates" + string(filepath.Separator)}, files)
+
+	files, err = templateFiles(dir, "f")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"feat.tmpl", "fix.tpl"}, files)
+}
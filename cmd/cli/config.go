@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/viper"
+)
+
+// configName is the base name of the config file searched for by default
+const configName = "tcommit"
+
+// configDirs returns the directories searched for tcommit.yaml:
+// the working directory, then $XDG_CONFIG_HOME/tcommit (~/.config/tcommit by default)
+func configDirs() []string {
+	dirs := []string{"."}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, configName))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", configName))
+	}
+	return dirs
+}
+
+// loadConfig reads the config file at path, or searches the config dirs when path is empty.
+// Settings from the file act as defaults that flags override.
+// Finding no config file is only an error when path is given.
+func loadConfig(path string) error {
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.SetConfigName(configName)
+		for _, dir := range configDirs() {
+			viper.AddConfigPath(dir)
+		}
+	}
+
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if path == "" && errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	return nil
+}
+
+// branchTemplate selects Template for branches matching Pattern.
+// The branch-templates config setting lists them in order of preference:
+//
+//	branch-templates:
+//	  - pattern: ^feature/
+//	    template: templates/feature.tmpl
+type branchTemplate struct {
+	Pattern  string `mapstructure:"pattern"`
+	Template string `mapstructure:"template"`
+}
+
+// templateForBranch returns the template of the first branch-templates entry
+// whose pattern matches branch, reporting false when none does
+func templateForBranch(branch string) (string, bool, error) {
+	var mappings []branchTemplate
+	if err := viper.UnmarshalKey("branch-templates", &mappings); err != nil {
+		return "", false, fmt.Errorf("invalid branch-templates: %w", err)
+	}
+
+	for _, m := range mappings {
+		pattern, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid branch-templates pattern %q: %w", m.Pattern, err)
+		}
+		if pattern.MatchString(branch) {
+			return m.Template, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// registerEnums registers the named choice sets of the enums config setting,
+// which templates reference as {{.type:@enum:committype}}:
+//
+//	enums:
+//	  committype: [feat, fix, docs, chore]
+func registerEnums() {
+	for name, choices := range viper.GetStringMapStringSlice("enums") {
+		template.RegisterEnum(name, choices)
+	}
+}
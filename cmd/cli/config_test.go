@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearConfig drops the settings read from a config file once the test finishes
+func clearConfig(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		viper.SetConfigType("yaml")
+		require.NoError(t, viper.ReadConfig(strings.NewReader("")))
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearConfig(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tcommit.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+template: templates/conventional.tmpl
+max-subject-length: 50
+conventional: true
+keys:
+  copy: ["y"]
+`), 0o644))
+
+	require.NoError(t, loadConfig(path))
+
+	assert.Equal(t, "templates/conventional.tmpl", viper.GetString("template"))
+	assert.Equal(t, 50, viper.GetInt("max-subject-length"))
+	assert.True(t, viper.GetBool("conventional"))
+	assert.Equal(t, map[string][]string{"copy": {"y"}}, viper.GetStringMapStringSlice("keys"))
+
+	// The settings drive message validation
+	err := checkMessage("Added a subject that is well beyond the fifty character limit")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at most 50 allowed")
+	assert.Contains(t, err.Error(), "bad format")
+	assert.NoError(t, checkMessage("feat: add login"))
+}
+
+func TestConfigTemplateDefault(t *testing.T) {
+	clearConfig(t)
+	resetFlags(t)
+	dir := t.TempDir()
+	tmplPath := writeTemplate(t, "chore: {{.desc}}")
+	configPath := filepath.Join(dir, "tcommit.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("template: "+tmplPath+"\n"), 0o644))
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("config", "")) })
+
+	// Without a template argument the configured one is rendered
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"--config", configPath, "-r", "desc=bump deps"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "chore: bump deps\n", out.String())
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	clearConfig(t)
+
+	// A missing explicit config file is an error
+	err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config")
+}
+
+// branchRunner is a git runner reporting a fixed current branch
+type branchRunner string
+
+func (b branchRunner) Run(args ...string) (string, error) {
+	if strings.Join(args, " ") == "rev-parse --abbrev-ref HEAD" {
+		return string(b), nil
+	}
+	return "", nil
+}
+
+func TestBranchTemplates(t *testing.T) {
+	clearConfig(t)
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("config", "")) })
+
+	feature := writeTemplate(t, "feat: {{.desc}}")
+	hotfix := writeTemplate(t, "fix: {{.desc}}")
+	fallback := writeTemplate(t, "chore: {{.desc}}")
+	configPath := filepath.Join(t.TempDir(), "tcommit.yaml")
+	writeConfig := func(template string) {
+		config := "branch-templates:\n" +
+			"  - pattern: ^(feature|feat)/\n    template: " + feature + "\n" +
+			"  - pattern: ^hotfix/\n    template: " + hotfix + "\n" +
+			"  - pattern: ^(hotfix|release)/\n    template: " + fallback + "\n"
+		if template != "" {
+			config += "template: " + template + "\n"
+		}
+		require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+	}
+	writeConfig(fallback)
+
+	restore := gitRunner
+	t.Cleanup(func() { gitRunner = restore })
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"feature/login", "feat: x\n"},
+		{"feat/login", "feat: x\n"},
+		// The first matching pattern wins
+		{"hotfix/crash", "fix: x\n"},
+		// Without a match the configured template is used
+		{"main", "chore: x\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.branch, func(t *testing.T) {
+			gitRunner = func() git.Runner { return branchRunner(tc.branch) }
+
+			var out strings.Builder
+			rootCmd.SetOut(&out)
+			rootCmd.SetArgs([]string{"--config", configPath, "-r", "desc=x"})
+			require.NoError(t, rootCmd.Execute())
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+
+	// An explicit template wins over the branch
+	gitRunner = func() git.Runner { return branchRunner("feature/login") }
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{fallback, "--config", configPath, "-r", "desc=x"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "chore: x\n", out.String())
+
+	// Without a match nor a default template there is nothing to render
+	writeConfig("")
+	gitRunner = func() git.Runner { return branchRunner("main") }
+	rootCmd.SetArgs([]string{"--config", configPath, "-r", "desc=x"})
+	assert.ErrorContains(t, rootCmd.Execute(), "none configured for the current branch")
+}
+
+func TestTemplateForBranchInvalidPattern(t *testing.T) {
+	clearConfig(t)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader("branch-templates:\n  - pattern: \"[\"\n    template: x.tmpl\n")))
+
+	_, _, err := templateForBranch("main")
+	assert.ErrorContains(t, err, "invalid branch-templates pattern")
+}
+
+func TestConfigEnums(t *testing.T) {
+	clearConfig(t)
+	resetFlags(t)
+	dir := t.TempDir()
+	tmplPath := writeTemplate(t, "{{.type:@enum:committype}}: {{.desc}}")
+	configPath := filepath.Join(dir, "tcommit.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("enums:\n  committype: [feat, fix]\n"), 0o644))
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("config", "")) })
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{tmplPath, "--config", configPath, "-r", "type=fix", "-r", "desc=typo"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix: typo\n", out.String())
+
+	rootCmd.SetArgs([]string{tmplPath, "--config", configPath, "-r", "type=chore", "-r", "desc=typo"})
+	assert.ErrorIs(t, rootCmd.Execute(), template.ErrInvalidValue)
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/spf13/cobra"
+)
+
+// defaultEditor is run when neither $EDITOR nor core.editor is set
+const defaultEditor = "vi"
+
+// editorCommand returns the editor to open messages with: $EDITOR,
+// then git config core.editor, then vi
+func editorCommand(r git.Runner) string {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	if editor, found, err := git.GetGitConfig(r, "core.editor"); err == nil && found && editor != "" {
+		return editor
+	}
+	return defaultEditor
+}
+
+// editMessage opens message in editor and returns the edited text.
+// The editor runs through the shell so it may carry arguments, e.g. "code --wait".
+// An editor failing or leaving the message empty aborts the commit.
+func editMessage(cmd *cobra.Command, editor, message string) (string, error) {
+	file, err := os.CreateTemp("", "tcommit-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create message file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, err = file.WriteString(message + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	run := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	run.Stdin = cmd.InOrStdin()
+	run.Stdout = cmd.OutOrStdout()
+	run.Stderr = cmd.ErrOrStderr()
+	if err := run.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed, aborting: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	edited := strings.TrimRight(string(data), " \t\r\n")
+	if strings.TrimSpace(edited) == "" {
+		return "", errors.New("edited message is empty, aborting")
+	}
+	return edited, nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEditor creates an editor script running body with the message file as $1
+func writeEditor(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub editor is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "editor")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755))
+	return path
+}
+
+func TestEditFlag(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.Flags().Set("edit", "false")) })
+	t.Setenv("EDITOR", writeEditor(t, `printf '\nRefs: #42\n' >> "$1"`))
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{writeTemplate(t, "feat: {{.desc}}"), "-r", "desc=add login", "--edit"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, "feat: add login\n\nRefs: #42\n", out.String())
+}
+
+func TestEditMessageAborts(t *testing.T) {
+	_, err := editMessage(rootCmd, writeEditor(t, "exit 1"), "feat: add login")
+	assert.ErrorContains(t, err, "aborting")
+
+	_, err = editMessage(rootCmd, writeEditor(t, `: > "$1"`), "feat: add login")
+	assert.ErrorContains(t, err, "empty")
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, "nano", editorCommand(&stubRunner{}))
+
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, defaultEditor, editorCommand(&stubRunner{}))
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/WhiCu/TCommit/internal/core/commit"
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/template"
+)
+
+// Exit codes scripts can rely on
+const (
+	ExitOK = 0
+	// ExitError is any failure without a more specific code, e.g. a bad flag
+	ExitError = 1
+	// ExitTemplate is a template that cannot be read, parsed or executed
+	ExitTemplate = 2
+	// ExitValidation is a value or commit message rejected by a constraint
+	ExitValidation = 3
+	// ExitGit is a failed git command or a repository not ready to commit
+	ExitGit = 4
+)
+
+// exit ends the process; tests replace it to observe the exit code
+var exit = os.Exit
+
+// templateErrors are the template failures reported with ExitTemplate
+var templateErrors = []error{
+	template.ErrInvalidTokenSyntax,
+	template.ErrNoReplacement,
+	template.ErrInvalidReplacement,
+	template.ErrIncludeUnsupported,
+	template.ErrIncludeCycle,
+	template.ErrInvalidPattern,
+	template.ErrInvalidLength,
+	template.ErrUnknownFunction,
+	template.ErrTooManyArguments,
+	template.ErrTooFewArguments,
+	template.ErrUnknownFilter,
+	template.ErrDefaultCycle,
+	template.ErrUnknownEnum,
+	template.ErrUnknownProvider,
+	template.ErrProvider,
+	template.ErrIsDirectory,
+	template.ErrUnclosedIf,
+	template.ErrUnexpectedKeyword,
+	template.ErrNoFilesSource,
+}
+
+// templateReadError is a template file that cannot be opened or read,
+// reported with ExitTemplate like the templates that cannot be parsed
+type templateReadError struct {
+	err error
+}
+
+func (e *templateReadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *templateReadError) Unwrap() error {
+	return e.err
+}
+
+func (e *templateReadError) ExitStatus() int {
+	return ExitTemplate
+}
+
+// exitCode returns the exit status for err: the one chosen by the error itself,
+// such as those of the check command, else the code of its class and
+// ExitError for anything unclassified
+func exitCode(err error) int {
+	var status interface{ ExitStatus() int }
+	if errors.As(err, &status) {
+		return status.ExitStatus()
+	}
+
+	var verr *commit.ValidationError
+	if errors.As(err, &verr) || errors.Is(err, template.ErrInvalidValue) ||
+		errors.Is(err, template.ErrNotBoolean) {
+		return ExitValidation
+	}
+	var gerr *git.GitError
+	if errors.As(err, &gerr) || errors.Is(err, git.ErrInvalidState) {
+		return ExitGit
+	}
+	for _, target := range templateErrors {
+		if errors.Is(err, target) {
+			return ExitTemplate
+		}
+	}
+	return ExitError
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCommit is a git runner in a clean repository state whose commit fails
+type failingCommit struct {
+	commitRecorder
+}
+
+func (f *failingCommit) Run(args ...string) (string, error) {
+	if args[0] == "commit" {
+		return "", &git.GitError{Command: strings.Join(args, " "), Err: errors.New("exit status 1"), ExitCode: 1}
+	}
+	return f.commitRecorder.Run(args...)
+}
+
+// nothingStaged is a git runner in a repository without staged changes
+type nothingStaged struct {
+	commitRecorder
+}
+
+func (n *nothingStaged) Run(args ...string) (string, error) {
+	if strings.Join(args, " ") == "diff --cached --name-only" {
+		return "", nil
+	}
+	return n.commitRecorder.Run(args...)
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		runner git.Runner
+		want   int
+	}{
+		{name: "success", args: []string{"--inline", "feat: {{.desc}}", "-r", "desc=x"}, want: ExitOK},
+		{name: "bad flag", args: []string{"--no-such-flag"}, want: ExitError},
+		{name: "missing template", args: []string{filepath.Join(t.TempDir(), "nosuch.tc")}, want: ExitTemplate},
+		{name: "parse error", args: []string{"--inline", "feat: {{.desc:len=x}}"}, want: ExitTemplate},
+		{name: "missing value", args: []string{"--inline", "feat: {{.desc}}"}, want: ExitTemplate},
+		{name: "unclosed if", args: []string{"--inline", "{{if .x}}feat: y"}, want: ExitTemplate},
+		{name: "not a boolean", args: []string{"--inline", "feat{{if .x}}!{{end}}: y", "-r", "x=maybe"}, want: ExitValidation},
+		{name: "rejected value", args: []string{"--inline", "{{.type:feat|fix}}: x", "-r", "type=wip"}, want: ExitValidation},
+		{name: "rejected message", args: []string{"--inline", "{{.desc}}", "-r", "desc=Added login", "--conventional"}, want: ExitValidation},
+		{name: "git state", args: []string{"--inline", "feat: {{.desc}}", "-r", "desc=x", "--execute"}, runner: &nothingStaged{}, want: ExitGit},
+		{name: "git command", args: []string{"--inline", "feat: {{.desc}}", "-r", "desc=x", "--execute"}, runner: &failingCommit{}, want: ExitGit},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetFlags(t)
+			t.Cleanup(func() {
+				require.NoError(t, rootCmd.Flags().Set("inline", ""))
+				require.NoError(t, rootCmd.PersistentFlags().Set("conventional", "false"))
+				rootCmd.SetErr(nil)
+			})
+			if tc.runner != nil {
+				restore := gitRunner
+				gitRunner = func() git.Runner { return tc.runner }
+				t.Cleanup(func() { gitRunner = restore })
+			}
+
+			code := ExitOK
+			restoreExit := exit
+			exit = func(c int) { code = c }
+			t.Cleanup(func() { exit = restoreExit })
+
+			var stderr strings.Builder
+			rootCmd.SetOut(&strings.Builder{})
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(tc.args)
+			Execute()
+
+			assert.Equal(t, tc.want, code, stderr.String())
+		})
+	}
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		// src is written to the template file, which is missing when empty
+		src  string
+		want int
+	}{
+		{name: "ok", src: "feat: {{.desc}}", want: ExitOK},
+		{name: "unreadable", want: ExitError},
+		{name: "unparsable", src: "feat: {{.desc:len=x}}", want: ExitTemplate},
+		{name: "missing key", src: "feat", want: 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetFlags(t)
+			t.Cleanup(func() { rootCmd.SetErr(nil) })
+
+			code := ExitOK
+			restoreExit := exit
+			exit = func(c int) { code = c }
+			t.Cleanup(func() { exit = restoreExit })
+
+			path := filepath.Join(t.TempDir(), "missing.tmpl")
+			if tc.src != "" {
+				path = writeTemplate(t, tc.src)
+			}
+			var stderr strings.Builder
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs([]string{"check", path, "--require", "desc"})
+			Execute()
+			assert.Equal(t, tc.want, code, stderr.String())
+		})
+	}
+}
+
+func TestExitCodeClassification(t *testing.T) {
+	// Codes chosen by the error win over its class
+	assert.Equal(t, 5, exitCode(fmt.Errorf("wrapped: %w", errors.Join(statusError(5), git.ErrInvalidState))))
+	assert.Equal(t, ExitGit, exitCode(&listError{title: "git validation failed", errs: []error{git.ErrInvalidState}}))
+	assert.Equal(t, ExitError, exitCode(errors.New("failed")))
+}
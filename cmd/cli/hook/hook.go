@@ -0,0 +1,121 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hookName is the git hook tcommit installs itself as
+const hookName = "prepare-commit-msg"
+
+// hookScript renders the hook for the given template. Messages that already
+// come from -m, a merge, a squash or an existing commit are left untouched.
+const hookScript = `#!/bin/sh
+# Installed by tcommit: fills the commit message from a template.
+# $1 is the commit message file, $2 the source of the message.
+case "$2" in
+message|merge|squash|commit) exit 0 ;;
+esac
+exec tcommit %s --output "$1"
+`
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// installHook writes the prepare-commit-msg hook for templatePath into the
+// repository's hooks directory and returns the hook path.
+// An existing hook is only replaced when force is set.
+func installHook(r git.Runner, templatePath string, force bool) (string, error) {
+	templatePath, err := filepath.Abs(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := git.HooksDir(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w", err)
+	}
+	path := filepath.Join(dir, hookName)
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("hook %s already exists (use --force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	script := fmt.Sprintf(hookScript, shellQuote(templatePath))
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o755); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+var installHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "Install tcommit as the prepare-commit-msg git hook",
+	Long: `Install a prepare-commit-msg hook that fills the commit message from a template
+whenever git commit opens the editor. An existing hook is only replaced with --force.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templatePath, err := cmd.Flags().GetString("template")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		runner := git.ExecRunner{
+			Dir: viper.GetString("repo"),
+			Bin: viper.GetString("git-bin"),
+		}
+
+		path, err := installHook(runner, templatePath, force)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Installed %s\n", path)
+		return nil
+	},
+}
+
+// GetCommand returns the install-hook command
+func GetCommand() *cobra.Command {
+	return installHookCmd
+}
+
+func init() {
+	installHookCmd.Flags().StringP("template", "t", "", "Template file the hook renders")
+	installHookCmd.Flags().BoolP("force", "f", false, "Overwrite an existing hook")
+
+	if err := installHookCmd.MarkFlagRequired("template"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marking flag: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := installHookCmd.RegisterFlagCompletionFunc("template", completion.TemplateFiles); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering completion: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates an empty repository in a temp dir
+func initRepo(t *testing.T) git.ExecRunner {
+	t.Helper()
+	dir := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return git.ExecRunner{Dir: dir}
+}
+
+func TestInstallHook(t *testing.T) {
+	r := initRepo(t)
+	templatePath := filepath.Join(t.TempDir(), "it's.tmpl")
+
+	path, err := installHook(r, templatePath, false)
+	require.NoError(t, err)
+
+	wantPath, err := filepath.EvalSymlinks(filepath.Join(r.Dir, ".git", "hooks", hookName))
+	require.NoError(t, err)
+	gotPath, err := filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+	assert.Equal(t, wantPath, gotPath)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	script, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(script), `exec tcommit '`+filepath.Dir(templatePath)+`/it'\''s.tmpl' --output "$1"`)
+}
+
+func TestInstallHookRequiresForce(t *testing.T) {
+	r := initRepo(t)
+
+	path, err := installHook(r, "first.tmpl", false)
+	require.NoError(t, err)
+
+	_, err = installHook(r, "second.tmpl", false)
+	require.ErrorContains(t, err, "--force")
+
+	script, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "first.tmpl")
+
+	_, err = installHook(r, "second.tmpl", true)
+	require.NoError(t, err)
+
+	script, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "second.tmpl")
+}
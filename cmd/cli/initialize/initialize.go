@@ -0,0 +1,67 @@
+package initialize
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultPath is where init writes the starter template
+const defaultPath = ".tcommit"
+
+// starterTemplate is a conventional-commit layout showing the token syntax:
+// a choice with a default, an optional scope with its parentheses in an if block,
+// a length limit and a free body
+const starterTemplate = `{{.type:@feat|fix|docs|style|refactor|perf|test|build|ci|chore}}{{if .scope?}}({{.scope}}){{end}}: {{.subject:len=1..72}}
+
+{{.body?}}
+`
+
+// writeTemplate writes the starter template to path.
+// An existing file is only replaced when force is set.
+func writeTemplate(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(path, []byte(starterTemplate), 0o644)
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init [path]",
+	Short: "Write a starter commit template",
+	Long: `Write a starter conventional-commit template to path (.tcommit by default)
+showing a choice with a default, an optional scope and a body.
+An existing file is only replaced with --force.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		path := defaultPath
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if err := writeTemplate(path, force); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", path)
+		return nil
+	},
+}
+
+// GetCommand returns the init command
+func GetCommand() *cobra.Command {
+	return initCmd
+}
+
+func init() {
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite an existing template")
+}
@@ -0,0 +1,67 @@
+package initialize
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/commit"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tcommit")
+	require.NoError(t, writeTemplate(path, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	tmpl, err := template.ParseString(string(data))
+	require.NoError(t, err)
+
+	// Without a scope the parentheses are left out, passing --conventional
+	msg, err := tmpl.Execute(template.ReplacerFuncFromMap(map[string]string{"subject": "add login"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login\n\n\n", msg)
+	assert.NoError(t, commit.ValidateConventional(msg))
+
+	msg, err = tmpl.Execute(template.ReplacerFuncFromMap(map[string]string{"scope": "api", "subject": "add login"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(api): add login\n\n\n", msg)
+	assert.NoError(t, commit.ValidateConventional(msg))
+
+	// A scope reading as a yes/no answer is kept all the same
+	msg, err = tmpl.Execute(template.ReplacerFuncFromMap(map[string]string{"scope": "no", "subject": "add login"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(no): add login\n\n\n", msg)
+}
+
+func TestWriteTemplateExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tcommit")
+	require.NoError(t, os.WriteFile(path, []byte("mine"), 0o644))
+
+	assert.ErrorContains(t, writeTemplate(path, false), "already exists")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(data))
+
+	require.NoError(t, writeTemplate(path, true))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, starterTemplate, string(data))
+}
+
+func TestInitCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+
+	var out bytes.Buffer
+	cmd := GetCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "Created "+path+"\n", out.String())
+	assert.FileExists(t, path)
+}
@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// lintTemplate reports problems in tmpl that would surface when rendering it
+// with the given replacements
+func lintTemplate(tmpl *template.Template, replacements map[string]string) []string {
+	var problems []string
+
+	// The first declaring use of each key, in order of appearance
+	seen := make(map[string]*template.VarNode)
+	var keys []string
+	for _, v := range tmpl.Variables() {
+		first, ok := seen[v.Key]
+		switch {
+		case !ok:
+			keys = append(keys, v.Key)
+			seen[v.Key] = v
+		case !isDeclaration(first):
+			seen[v.Key] = v
+		case isDeclaration(v) && !reflect.DeepEqual(first, v):
+			problems = append(problems, fmt.Sprintf("duplicate key %q with conflicting declarations", v.Key))
+		}
+	}
+
+	for _, key := range keys {
+		v := seen[key]
+		if _, ok := replacements[v.Key]; !ok && !v.HasDef && !v.Optional {
+			problems = append(problems, fmt.Sprintf("variable %q has no default and no replacement", v.Key))
+		}
+	}
+
+	return problems
+}
+
+// isDeclaration reports whether v declares choices or a default rather than
+// just referencing its key
+func isDeclaration(v *template.VarNode) bool {
+	return v.HasDef || v.Optional || len(v.Choices) > 0 || v.Pattern != nil || v.MinLen > 0 || v.MaxLen > 0
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <template>",
+	Short: "Report template problems without rendering",
+	Long: `Parse a template and report problems without rendering it:
+invalid tokens, duplicate keys with conflicting declarations and variables
+that have neither a default nor a replacement.
+
+Replacements given with --replace count as provided values.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completion.SingleTemplate,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		var problems []string
+		var pathErr *fs.PathError
+		tmpl, err := template.ParseFile(args[0])
+		switch {
+		case errors.As(err, &pathErr):
+			return fmt.Errorf("failed to read template file: %w", err)
+		case err != nil:
+			// Parse errors already name the offending file
+			problems = append(problems, err.Error())
+		default:
+			for _, problem := range lintTemplate(tmpl, viper.GetStringMapString("replacements")) {
+				problems = append(problems, args[0]+": "+problem)
+			}
+		}
+
+		for _, problem := range problems {
+			fmt.Fprintln(cmd.OutOrStdout(), problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("found %d problem(s) in %s", len(problems), args[0])
+		}
+
+		return nil
+	},
+}
+
+// GetCommand returns the lint command
+func GetCommand() *cobra.Command {
+	return lintCmd
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string
+		replacements map[string]string
+		want         []string
+	}{
+		{
+			name:     "Healthy template",
+			template: "{{.type:feat|@fix}}: {{.desc}} ({{.type}})",
+			replacements: map[string]string{
+				"desc": "typo",
+			},
+			want: nil,
+		},
+		{
+			name:         "Repeated pattern declaration",
+			template:     "{{.ticket:/^[A-Z]+-\\d+$/}} {{.ticket:/^[A-Z]+-\\d+$/}}",
+			replacements: map[string]string{"ticket": "ABC-1"},
+			want:         nil,
+		},
+		{
+			name:         "Conflicting patterns",
+			template:     "{{.ticket:/^[A-Z]+$/}} {{.ticket:/^[0-9]+$/}}",
+			replacements: map[string]string{"ticket": "ABC-1"},
+			want:         []string{`duplicate key "ticket" with conflicting declarations`},
+		},
+		{
+			name:         "Conflicting duplicate key",
+			template:     "{{.type:feat|@fix}} {{.type:@docs}}",
+			replacements: map[string]string{},
+			want:         []string{`duplicate key "type" with conflicting declarations`},
+		},
+		{
+			name:         "Optional variable",
+			template:     "{{.type:@feat}}({{.scope?}}): {{.desc}} {{.scope}}",
+			replacements: map[string]string{"desc": "typo"},
+			want:         nil,
+		},
+		{
+			name:         "Missing value source",
+			template:     "{{.type:@feat}}: {{.desc}}",
+			replacements: map[string]string{},
+			want:         []string{`variable "desc" has no default and no replacement`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := template.ParseString(tc.template)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, lintTemplate(tmpl, tc.replacements))
+		})
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		template string
+		wantOut  string
+		wantErr  bool
+	}{
+		{
+			name:     "Invalid token",
+			template: "Hello {{name}}!",
+			wantOut:  "invalid token syntax",
+			wantErr:  true,
+		},
+		{
+			name:     "No problems",
+			template: "Hello {{.name:@World}}!",
+			wantOut:  "",
+			wantErr:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "commit.tmpl")
+			require.NoError(t, os.WriteFile(path, []byte(tc.template), 0o644))
+
+			var out bytes.Buffer
+			cmd := GetCommand()
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			cmd.SetArgs([]string{path})
+
+			err := cmd.Execute()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), tc.wantOut)
+		})
+	}
+}
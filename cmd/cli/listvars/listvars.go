@@ -0,0 +1,109 @@
+package listvars
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+)
+
+// variable is the printed description of a template variable
+type variable struct {
+	Key        string   `json:"key"`
+	HasDefault bool     `json:"has_default"`
+	Default    string   `json:"default"`
+	Choices    []string `json:"choices"`
+}
+
+// collectVariables returns one entry per key in order of first appearance,
+// described by the first use that declares choices or a default
+func collectVariables(tmpl *template.Template) []variable {
+	index := make(map[string]int)
+	vars := make([]variable, 0)
+	for _, v := range tmpl.Variables() {
+		i, ok := index[v.Key]
+		if !ok {
+			i = len(vars)
+			index[v.Key] = i
+			vars = append(vars, variable{Key: v.Key, Choices: []string{}})
+		}
+		if vars[i].HasDefault || len(vars[i].Choices) > 0 {
+			continue
+		}
+		vars[i].HasDefault = v.HasDef
+		vars[i].Default = v.Default
+		if v.DefaultNodes != nil {
+			// Render computed defaults, marking the variables they need
+			if def, err := v.RenderDefault(template.PreviewReplacer(template.ReplacerFuncFromMap(nil))); err == nil {
+				vars[i].Default = def
+			}
+		}
+		vars[i].Choices = append(vars[i].Choices, v.Choices...)
+	}
+	return vars
+}
+
+// writeTable prints vars as an aligned human-readable table
+func writeTable(w io.Writer, vars []variable) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tHAS DEFAULT\tDEFAULT\tCHOICES")
+	for _, v := range vars {
+		choices := strings.Join(v.Choices, "|")
+		if choices == "" {
+			choices = "-"
+		}
+		def := "-"
+		if v.HasDefault {
+			def = v.Default
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\n", v.Key, v.HasDefault, def, choices)
+	}
+	return tw.Flush()
+}
+
+// writeJSON prints vars as an indented JSON array
+func writeJSON(w io.Writer, vars []variable) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vars)
+}
+
+var listVarsCmd = &cobra.Command{
+	Use:   "list-vars <template>",
+	Short: "Print the variables of a template",
+	Long: `Print every variable a template uses together with its default and choices.
+Use --json for machine-readable output.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completion.SingleTemplate,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tmpl, err := template.ParseFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+
+		vars := collectVariables(tmpl)
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return writeJSON(cmd.OutOrStdout(), vars)
+		}
+		return writeTable(cmd.OutOrStdout(), vars)
+	},
+}
+
+// GetCommand returns the list-vars command
+func GetCommand() *cobra.Command {
+	return listVarsCmd
+}
+
+func init() {
+	listVarsCmd.Flags().Bool("json", false, "Print variables as JSON")
+}
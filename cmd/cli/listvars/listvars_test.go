@@ -0,0 +1,47 @@
+package listvars
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = "{{.type:feat|fix|@docs}}({{.scope:@}}): {{.desc}}\n\n{{.type}} {{.ref:@{{.type}} {{.issue}}}}"
+
+// run executes the command on a temp file holding sample and returns its output
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(sample), 0o644))
+
+	var out bytes.Buffer
+	cmd := GetCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs(append([]string{path}, args...))
+	require.NoError(t, cmd.Execute())
+	return out.String()
+}
+
+func TestListVarsTable(t *testing.T) {
+	want := "" +
+		"KEY    HAS DEFAULT  DEFAULT           CHOICES\n" +
+		"type   true         docs              feat|fix|docs\n" +
+		"scope  true                           -\n" +
+		"desc   false        -                 -\n" +
+		"ref    true         <type?> <issue?>  -\n"
+	assert.Equal(t, want, run(t, "--json=false"))
+}
+
+func TestListVarsJSON(t *testing.T) {
+	want := `[
+  {"key": "type", "has_default": true, "default": "docs", "choices": ["feat", "fix", "docs"]},
+  {"key": "scope", "has_default": true, "default": "", "choices": []},
+  {"key": "desc", "has_default": false, "default": "", "choices": []},
+  {"key": "ref", "has_default": true, "default": "<type?> <issue?>", "choices": []}
+]`
+	assert.JSONEq(t, want, run(t, "--json"))
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+)
+
+// Level controls how much the CLI writes besides errors
+type Level int
+
+const (
+	// LevelQuiet suppresses everything but errors
+	LevelQuiet Level = iota
+	// LevelNormal prints the generated message
+	LevelNormal
+	// LevelVerbose additionally logs parsing, variables and git commands
+	LevelVerbose
+)
+
+// logger is a minimal leveled logger.
+// Regular output goes to out, diagnostics go to err so they never end up in a piped message.
+type logger struct {
+	out   io.Writer
+	err   io.Writer
+	level Level
+}
+
+// newLogger creates a logger for the level selected by the verbose and quiet flags
+func newLogger(out, err io.Writer, verbose, quiet bool) *logger {
+	level := LevelNormal
+	switch {
+	case quiet:
+		level = LevelQuiet
+	case verbose:
+		level = LevelVerbose
+	}
+	return &logger{out: out, err: err, level: level}
+}
+
+// Println writes regular output unless the logger is quiet
+func (l *logger) Println(a ...any) {
+	if l.level >= LevelNormal {
+		fmt.Fprintln(l.out, a...)
+	}
+}
+
+// Debugf writes a diagnostic line when the logger is verbose
+func (l *logger) Debugf(format string, args ...any) {
+	if l.level >= LevelVerbose {
+		fmt.Fprintf(l.err, format+"\n", args...)
+	}
+}
+
+// loggingRunner logs every git invocation before delegating to the wrapped runner
+type loggingRunner struct {
+	git.Runner
+	log *logger
+	// printCommands prints the commands that change the repository to stderr
+	// at any level, so users can audit exactly what is executed
+	printCommands bool
+}
+
+// changesRepository lists the git commands tcommit runs that modify the repository
+var changesRepository = map[string]bool{
+	"commit": true,
+	"push":   true,
+}
+
+// trace reports a git invocation about to run
+func (r loggingRunner) trace(args []string) {
+	if r.printCommands && len(args) > 0 && changesRepository[args[0]] {
+		fmt.Fprintln(r.log.err, formatArgv(args))
+		return
+	}
+	r.log.Debugf("running %s", formatArgv(args))
+}
+
+// Run logs and executes a git command
+func (r loggingRunner) Run(args ...string) (string, error) {
+	r.trace(args)
+	return r.Runner.Run(args...)
+}
+
+// RunAttached logs and executes a git command attached to the stdio,
+// falling back to Run when the wrapped runner cannot attach
+func (r loggingRunner) RunAttached(args ...string) error {
+	r.trace(args)
+	if a, ok := r.Runner.(git.AttachedRunner); ok {
+		return a.RunAttached(args...)
+	}
+	_, err := r.Runner.Run(args...)
+	return err
+}
+
+// safeArg matches arguments that need no quoting in a POSIX shell
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// formatArgv renders a git invocation as a POSIX shell command line,
+// single-quoting the arguments that need it
+func formatArgv(args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, "git")
+	for _, arg := range args {
+		if !safeArg.MatchString(arg) {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
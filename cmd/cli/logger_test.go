@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRunner records git invocations without running anything
+type stubRunner struct {
+	calls []string
+}
+
+func (s *stubRunner) Run(args ...string) (string, error) {
+	s.calls = append(s.calls, strings.Join(args, " "))
+	return "", nil
+}
+
+func TestVerboseLogsGitArgv(t *testing.T) {
+	var out, errOut bytes.Buffer
+	log := newLogger(&out, &errOut, true, false)
+	stub := &stubRunner{}
+
+	runner := loggingRunner{Runner: stub, log: log}
+	require.NoError(t, git.Commit(runner, git.CommitOptions{Message: "feat: add login", Amend: true}))
+
+	require.NotEmpty(t, stub.calls)
+	assert.Regexp(t, `^commit -F \S+ --amend$`, stub.calls[len(stub.calls)-1])
+	assert.Regexp(t, `git commit -F \S+ --amend`, errOut.String())
+	assert.Empty(t, out.String())
+}
+
+func TestVerboseLogsTemplateDetails(t *testing.T) {
+	var errOut bytes.Buffer
+	log := newLogger(&bytes.Buffer{}, &errOut, true, false)
+
+	_, err := processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.type}}: {{.desc:@wip}}"),
+		Replacements: map[string]string{"type": "fix"},
+	}, log)
+	require.NoError(t, err)
+
+	assert.Contains(t, errOut.String(), "3 node(s)")
+	assert.Contains(t, errOut.String(), `variable type = "fix"`)
+	assert.Contains(t, errOut.String(), `variable desc = "wip"`)
+}
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbose   bool
+		quiet     bool
+		wantOut   string
+		wantDebug string
+	}{
+		{name: "Normal", wantOut: "message\n"},
+		{name: "Verbose", verbose: true, wantOut: "message\n", wantDebug: "debug\n"},
+		{name: "Quiet", quiet: true},
+		{name: "Quiet wins over verbose", verbose: true, quiet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			log := newLogger(&out, &errOut, tt.verbose, tt.quiet)
+
+			log.Println("message")
+			log.Debugf("debug")
+
+			assert.Equal(t, tt.wantOut, out.String())
+			assert.Equal(t, tt.wantDebug, errOut.String())
+		})
+	}
+}
+
+func TestPrintCommand(t *testing.T) {
+	var errOut bytes.Buffer
+	log := newLogger(&bytes.Buffer{}, &errOut, false, true)
+	stub := &stubRunner{}
+
+	runner := loggingRunner{Runner: stub, log: log, printCommands: true}
+	require.NoError(t, git.Commit(runner, git.CommitOptions{
+		MessageFile: "/tmp/msg.txt",
+		Amend:       true,
+		Author:      "Jane O'Neil <jane@example.com>",
+		Date:        "2024-01-02T03:04:05",
+	}))
+
+	// Only the commit is printed, even when quiet
+	assert.Equal(t, `git commit -F /tmp/msg.txt --amend '--author=Jane O'\''Neil <jane@example.com>' --date=2024-01-02T03:04:05`+"\n", errOut.String())
+}
+
+func TestFormatArgv(t *testing.T) {
+	assert.Equal(t, "git push origin feature/login", formatArgv([]string{"push", "origin", "feature/login"}))
+	assert.Equal(t, `git commit -m 'feat: add "login"' ''`, formatArgv([]string{"commit", "-m", `feat: add "login"`, ""}))
+}
@@ -0,0 +1,80 @@
+package preview
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// render parses and previews the template at path
+func render(path string) (string, error) {
+	tmpl, err := template.ParseFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	replacer := template.PreviewReplacer(template.ReplacerFuncFromMap(viper.GetStringMapString("replacements")))
+	message, err := tmpl.Execute(replacer)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return message, nil
+}
+
+// renderTo writes the preview of path to out, or the error to errOut
+func renderTo(out, errOut io.Writer, path string) {
+	message, err := render(path)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(out, message)
+}
+
+var previewCmd = &cobra.Command{
+	Use:   "preview <template>",
+	Short: "Render a template without requiring every value",
+	Long: `Render a template for authoring: values given with --replace are used,
+defaults fill in the rest and variables with neither show as <key?> markers
+instead of failing.
+
+With --watch the template is rendered again whenever the file changes;
+errors are printed and watching goes on until interrupted.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completion.SingleTemplate,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		watch, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			return err
+		}
+		if watch {
+			changes := watchFile(cmd.Context(), args[0])
+			renderTo(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0])
+			for range changes {
+				renderTo(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0])
+			}
+			return nil
+		}
+
+		message, err := render(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), message)
+		return nil
+	},
+}
+
+// GetCommand returns the preview command
+func GetCommand() *cobra.Command {
+	return previewCmd
+}
+
+func init() {
+	previewCmd.Flags().BoolP("watch", "w", false, "Render again whenever the template file changes")
+}
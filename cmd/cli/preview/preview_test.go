@@ -0,0 +1,119 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{.type:feat|@fix}}({{.scope}}): {{.desc}}"), 0o644))
+
+	viper.Set("replacements", map[string]string{"desc": "add login"})
+	t.Cleanup(func() { viper.Set("replacements", nil) })
+
+	var out bytes.Buffer
+	cmd := GetCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "fix(<scope?>): add login\n", out.String())
+}
+
+func TestPreviewLeavesCounters(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	counters := filepath.Join(dir, "tcommit", "counters.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(counters), 0o755))
+	require.NoError(t, os.WriteFile(counters, []byte(`{"build": 4}`), 0o644))
+
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`build #{{counter "build"}}`), 0o644))
+
+	for range 2 {
+		var out bytes.Buffer
+		cmd := GetCommand()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{path})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "build #5\n", out.String(), "the next value is shown")
+	}
+
+	data, err := os.ReadFile(counters)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"build": 4}`, string(data))
+}
+
+// renderWriter signals every render written to it
+type renderWriter struct {
+	bytes.Buffer
+	rendered chan struct{}
+}
+
+func (w *renderWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	w.rendered <- struct{}{}
+	return n, err
+}
+
+func TestPreviewWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("feat: {{.desc:@first}}"), 0o644))
+
+	changes := make(chan struct{})
+	restore := watchFile
+	watchFile = func(ctx context.Context, watched string) <-chan struct{} {
+		assert.Equal(t, path, watched)
+		return changes
+	}
+	t.Cleanup(func() { watchFile = restore })
+
+	rendered := make(chan struct{})
+	out := &renderWriter{rendered: rendered}
+	errOut := &renderWriter{rendered: rendered}
+
+	go func() {
+		for _, src := range []string{
+			"fix: {{.desc:@second}}",
+			// Render errors are reported without ending the watch
+			"fix: {{.desc:len=x}}",
+			"docs: {{.desc:@third}}",
+		} {
+			<-rendered
+			assert.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+			changes <- struct{}{}
+		}
+		<-rendered
+		close(changes)
+	}()
+
+	cmd := GetCommand()
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+	cmd.SetArgs([]string{path, "--watch"})
+	t.Cleanup(func() { require.NoError(t, cmd.Flags().Set("watch", "false")) })
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "feat: first\nfix: second\ndocs: third\n", out.String())
+	assert.Contains(t, errOut.String(), "invalid length constraint")
+}
+
+func TestFileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	assert.Equal(t, version{}, fileVersion(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("feat"), 0o644))
+	first := fileVersion(path)
+	assert.True(t, first.exists)
+
+	require.NoError(t, os.WriteFile(path, []byte("feat: more"), 0o644))
+	assert.NotEqual(t, first, fileVersion(path))
+}
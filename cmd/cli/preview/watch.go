@@ -0,0 +1,66 @@
+package preview
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// pollInterval is how often a watched file is checked for changes
+const pollInterval = 300 * time.Millisecond
+
+// watchFile returns a channel receiving a value whenever the file at path changes.
+// The channel is closed once ctx is done. Tests replace it to drive changes.
+var watchFile = pollFile
+
+// pollFile watches path by comparing its modification time and size at every
+// pollInterval, which works on every platform without extra dependencies.
+// A file that disappears and comes back counts as a change.
+func pollFile(ctx context.Context, path string) <-chan struct{} {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+
+		last := fileVersion(path)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current := fileVersion(path)
+			if current == last {
+				continue
+			}
+			last = current
+			select {
+			case changes <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+// version identifies a state of a file's content
+type version struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// fileVersion returns the current version of the file at path
+func fileVersion(path string) version {
+	info, err := os.Stat(path)
+	if err != nil {
+		return version{}
+	}
+	return version{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
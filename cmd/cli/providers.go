@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/template"
+)
+
+// recentAuthorsDepth is how many commits the authors provider looks through
+const recentAuthorsDepth = 100
+
+// recentScopesDepth is how many commits the scopes provider looks through
+const recentScopesDepth = 200
+
+// registerProviders registers the choice providers templates reference as
+// {{.key:@cmd:name}}: dirs lists the top-level directories of dir, authors the
+// recent commit authors and scopes the scopes of recent conventional commits.
+// {{files}} lists the staged files with r.
+func registerProviders(r git.Runner, dir string) {
+	template.SetStagedFilesSource(func() ([]string, error) {
+		return git.GetStagedFiles(r)
+	})
+	template.RegisterChoiceProvider("dirs", func() ([]string, error) {
+		return topLevelDirs(dir)
+	})
+	template.RegisterChoiceProvider("authors", func() ([]string, error) {
+		return git.GetRecentAuthors(r, recentAuthorsDepth)
+	})
+	template.RegisterChoiceProvider("scopes", func() ([]string, error) {
+		return git.RecentScopes(r, recentScopesDepth)
+	})
+}
+
+// topLevelDirs returns the names of the directories in dir, skipping hidden ones
+func topLevelDirs(dir string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorsRunner answers git log with fixed authors or subjects and git diff with staged files
+type authorsRunner struct{}
+
+func (authorsRunner) Run(args ...string) (string, error) {
+	switch {
+	case args[0] == "diff":
+		return "a.go\nb.go", nil
+	case args[len(args)-1] == "--format=%s":
+		return "feat(api): x\nfix: y\nfix(cli): z\nfix(api): w", nil
+	}
+	return "Jane\nBob\nJane", nil
+}
+
+func TestProviders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api", "cli", ".git"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0o644))
+	registerProviders(authorsRunner{}, dir)
+
+	tmpl, err := template.ParseString("{{.scope:@cmd:dirs}} {{.author:@cmd:authors}} {{.recent:@cmd:scopes}}")
+	require.NoError(t, err)
+	vars := tmpl.Variables()
+	assert.Equal(t, []string{"api", "cli"}, vars[0].Choices)
+	assert.Equal(t, []string{"Jane", "Bob"}, vars[1].Choices)
+	assert.Equal(t, []string{"api", "cli"}, vars[2].Choices)
+
+	_, err = tmpl.Execute(template.ReplacerFuncFromMap(map[string]string{"scope": "docs", "author": "Jane", "recent": "api"}))
+	assert.ErrorIs(t, err, template.ErrInvalidValue)
+
+	tmpl, err = template.ParseString(`{{files " "}}`)
+	require.NoError(t, err)
+	files, err := tmpl.Execute(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "a.go b.go", files)
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WhiCu/TCommit/internal/core/history"
+	"github.com/WhiCu/TCommit/internal/core/template"
+)
+
+// remoteTimeout bounds fetching a remote template
+const remoteTimeout = 10 * time.Second
+
+// httpClient fetches remote templates
+var httpClient = &http.Client{Timeout: remoteTimeout}
+
+// remoteCacheDir returns the directory caching remote templates; replaced in tests
+var remoteCacheDir = func() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// isRemote reports whether path names a template served over HTTP(S)
+func isRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// cachedTemplate is a remote template kept with the ETag it was served with
+type cachedTemplate struct {
+	ETag string `json:"etag"`
+	Body string `json:"body"`
+}
+
+// remoteCachePath returns the cache file of url
+func remoteCachePath(url string) (string, error) {
+	dir, err := remoteCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// fetchTemplate downloads and parses the template at url. A template served
+// with an ETag is cached, so it is only downloaded again once it changed.
+// Failing to use the cache never fails the fetch.
+func fetchTemplate(url string) (*template.Template, error) {
+	var cached cachedTemplate
+	cachePath, err := remoteCachePath(url)
+	if err == nil {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			_ = json.Unmarshal(data, &cached)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached.ETag != "":
+		return template.ParseString(cached.Body)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch template %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && cachePath != "" {
+		if data, err := json.Marshal(cachedTemplate{ETag: etag, Body: string(body)}); err == nil {
+			if os.MkdirAll(filepath.Dir(cachePath), 0o755) == nil {
+				_ = os.WriteFile(cachePath, data, 0o644)
+			}
+		}
+	}
+	return template.Parse(bytes.NewReader(body))
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteTemplate(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("allow-remote", "false")) })
+
+	cacheDir := t.TempDir()
+	restore := remoteCacheDir
+	remoteCacheDir = func() (string, error) { return cacheDir, nil }
+	t.Cleanup(func() { remoteCacheDir = restore })
+
+	var downloads, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("{{.type:@feat}}: {{.desc}}"))
+	}))
+	t.Cleanup(server.Close)
+
+	// Remote templates must be allowed explicitly
+	rootCmd.SetOut(&strings.Builder{})
+	rootCmd.SetArgs([]string{server.URL + "/commit.tmpl", "-r", "desc=shared"})
+	assert.ErrorContains(t, rootCmd.Execute(), "--allow-remote")
+	assert.Zero(t, downloads)
+
+	for range 2 {
+		var out strings.Builder
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{server.URL + "/commit.tmpl", "--allow-remote", "-r", "desc=shared"})
+		require.NoError(t, rootCmd.Execute())
+		assert.Equal(t, "feat: shared\n", out.String())
+	}
+	// The second run reused the cached copy
+	assert.Equal(t, 1, downloads)
+	assert.Equal(t, 1, notModified)
+}
+
+func TestRemoteTemplateStatus(t *testing.T) {
+	restore := remoteCacheDir
+	remoteCacheDir = func() (string, error) { return t.TempDir(), nil }
+	t.Cleanup(func() { remoteCacheDir = restore })
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	_, err := fetchTemplate(server.URL + "/missing.tmpl")
+	assert.ErrorContains(t, err, "404 Not Found")
+	assert.True(t, isRemote("https://example.com/t.tmpl"))
+	assert.False(t, isRemote("templates/t.tmpl"))
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"sync"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/viper"
+)
+
+// ViperReplacer returns a Replacer serving the settings of v, so that
+// {{.author.name}} resolves to v.GetString("author.name").
+// Only keys that are set are found; namespaces holding nested settings are not values.
+func ViperReplacer(v *viper.Viper) template.Replacer {
+	return template.ReplacerFunc(func(key string) (string, bool) {
+		if !v.IsSet(key) {
+			return "", false
+		}
+		switch v.Get(key).(type) {
+		case map[string]any, map[string]string:
+			return "", false
+		}
+		return v.GetString(key), true
+	})
+}
+
+// varsKey is the config setting whose entries fill in template variables:
+//
+//	vars:
+//	  author:
+//	    name: Jane
+//
+// Other settings, including those of flags and the ones the tool sets for
+// itself such as message, never reach templates.
+const varsKey = "vars"
+
+// configVars returns the vars setting of v as its own instance, empty when unset
+func configVars(v *viper.Viper) *viper.Viper {
+	if sub := v.Sub(varsKey); sub != nil {
+		return sub
+	}
+	return viper.New()
+}
+
+// gitConfigKeys maps template keys to the git config keys serving them
+var gitConfigKeys = map[string]string{
+	"author": "user.name",
+	"email":  "user.email",
+}
+
+// GitConfigReplacer returns a Replacer serving {{.author}} and {{.email}}
+// from git config user.name and user.email.
+// Git is only queried for keys the template looks up, once per key.
+// Unset keys and git failures are reported as not found.
+func GitConfigReplacer(r git.Runner) template.Replacer {
+	lookups := make(map[string]func() (string, bool), len(gitConfigKeys))
+	for key, configKey := range gitConfigKeys {
+		lookups[key] = sync.OnceValues(func() (string, bool) {
+			value, found, err := git.GetGitConfig(r, configKey)
+			return value, found && err == nil
+		})
+	}
+	return template.LazyReplacer(lookups)
+}
+
+// chainReplacers looks keys up in each replacer in turn, returning the first value found
+func chainReplacers(replacers ...template.Replacer) template.Replacer {
+	return template.ReplacerFunc(func(key string) (string, bool) {
+		for _, r := range replacers {
+			if r == nil {
+				continue
+			}
+			if val, ok := r.Get(key); ok {
+				return val, true
+			}
+		}
+		return "", false
+	})
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViperReplacer(t *testing.T) {
+	v := viper.New()
+	v.Set("author.name", "Jane")
+	v.Set("issue", 42)
+
+	r := ViperReplacer(v)
+
+	val, ok := r.Get("author.name")
+	assert.True(t, ok)
+	assert.Equal(t, "Jane", val)
+
+	val, ok = r.Get("issue")
+	assert.True(t, ok)
+	assert.Equal(t, "42", val)
+
+	_, ok = r.Get("author")
+	assert.False(t, ok, "a namespace is not a value")
+
+	_, ok = r.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestConfigVars(t *testing.T) {
+	v := viper.New()
+	v.Set("vars.author.name", "Jane")
+	v.Set("message", "")
+	v.Set("output", "msg.txt")
+
+	r := ViperReplacer(configVars(v))
+	val, ok := r.Get("author.name")
+	assert.True(t, ok)
+	assert.Equal(t, "Jane", val)
+
+	// Settings of the tool itself are not template values
+	for _, key := range []string{"message", "output", "vars"} {
+		_, ok = r.Get(key)
+		assert.False(t, ok, key)
+	}
+
+	_, ok = ViperReplacer(configVars(viper.New())).Get("author.name")
+	assert.False(t, ok)
+}
+
+func TestInternalSettingsNotServed(t *testing.T) {
+	resetFlags(t)
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{writeTemplate(t, "{{.type:@feat}}: {{.message}}")})
+	assert.ErrorIs(t, rootCmd.Execute(), template.ErrNoReplacement)
+
+	out.Reset()
+
+	rootCmd.SetArgs([]string{writeTemplate(t, "{{.type:@feat}}: {{.message:@wip}} {{.output:@-}}")})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: wip -\n", out.String())
+}
+
+func TestProcessTemplateSettings(t *testing.T) {
+	v := viper.New()
+	v.Set("author.name", "Jane")
+	v.Set("type", "chore")
+
+	result, err := processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.type}}: {{.desc}} by {{.author.name}}"),
+		Replacements: map[string]string{"type": "feat", "desc": "add login"},
+		Settings:     ViperReplacer(v),
+	}, newLogger(io.Discard, io.Discard, false, false))
+	require.NoError(t, err)
+
+	// Explicit replacements take precedence over settings
+	assert.Equal(t, "feat: add login by Jane", result.Message)
+	assert.Equal(t, "Jane", result.Variables["author.name"])
+}
+
+func TestGitConfigReplacer(t *testing.T) {
+	// Ignore the user's and the system's config
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	dir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.name", "Jane Doe"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	runner := git.ExecRunner{Dir: dir}
+
+	result, err := processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.desc}} by {{.author}} <{{.email:@unknown}}>"),
+		Replacements: map[string]string{"desc": "add login"},
+		GitConfig:    GitConfigReplacer(runner),
+	}, newLogger(io.Discard, io.Discard, false, false))
+	require.NoError(t, err)
+	assert.Equal(t, "add login by Jane Doe <unknown>", result.Message)
+
+	// Explicit replacements take precedence
+	result, err = processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.author}}"),
+		Replacements: map[string]string{"author": "John"},
+		GitConfig:    GitConfigReplacer(runner),
+	}, newLogger(io.Discard, io.Discard, false, false))
+	require.NoError(t, err)
+	assert.Equal(t, "John", result.Message)
+}
@@ -1,12 +1,28 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/WhiCu/TCommit/cmd/cli/batch"
 	"github.com/WhiCu/TCommit/cmd/cli/bubble"
+	"github.com/WhiCu/TCommit/cmd/cli/check"
+	"github.com/WhiCu/TCommit/cmd/cli/completion"
+	"github.com/WhiCu/TCommit/cmd/cli/hook"
+	"github.com/WhiCu/TCommit/cmd/cli/initialize"
+	"github.com/WhiCu/TCommit/cmd/cli/lint"
+	"github.com/WhiCu/TCommit/cmd/cli/listvars"
+	"github.com/WhiCu/TCommit/cmd/cli/preview"
+	"github.com/WhiCu/TCommit/internal/core/commit"
 	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/history"
 	"github.com/WhiCu/TCommit/internal/core/template"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -16,7 +32,19 @@ import (
 type Config struct {
 	Replacements map[string]string
 	TemplateFile string
-	ExecuteGit   bool
+	// InlineTemplate, when set, is parsed instead of reading TemplateFile
+	InlineTemplate string
+	ExecuteGit     bool
+	// FallbackTemplate is rendered instead of TemplateFile when the file is missing or empty
+	FallbackTemplate string
+	// Settings provides values for the keys missing from Replacements, e.g. the vars config setting
+	Settings template.Replacer
+	// GitConfig provides the values missing from Settings from git config, e.g. the author
+	GitConfig template.Replacer
+	// Remembered holds the values last used with the template, preferred over template defaults
+	Remembered map[string]string
+	// EscapeOutput escapes marker-like sequences in the values, see template.EscapeMarkers
+	EscapeOutput bool
 }
 
 // parseReplacements parses the replacement flags into a map
@@ -37,30 +65,296 @@ func parseReplacements(replaceFlags []string) (map[string]string, error) {
 	return replacements, nil
 }
 
-// processTemplate processes the template file with the given replacements
-func processTemplate(cfg *Config) (string, error) {
-	file, err := os.Open(cfg.TemplateFile)
+// parseReplacementsJSON parses the JSON object given with --replace-json into a map.
+// An empty string is an empty map.
+func parseReplacementsJSON(data string) (map[string]string, error) {
+	replacements := make(map[string]string)
+	if strings.TrimSpace(data) == "" {
+		return replacements, nil
+	}
+	if err := json.Unmarshal([]byte(data), &replacements); err != nil {
+		return nil, fmt.Errorf(`invalid --replace-json (expected an object of strings such as {"type":"feat"}): %w`, err)
+	}
+	return replacements, nil
+}
+
+// splitArgs separates the template argument from the positional values given after "--"
+func splitArgs(cmd *cobra.Command, args []string) (templateArgs, positional []string) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		return args, nil
+	}
+	// Everything after the dash is a value, so that --inline or a configured
+	// template can take positional values too: tcommit -- feat auth
+	return args[:dash], args[dash:]
+}
+
+// positionalReplacements maps values to the numeric keys {{.1}}, {{.2}}, ...
+func positionalReplacements(values []string) map[string]string {
+	replacements := make(map[string]string, len(values))
+	for i, value := range values {
+		replacements[strconv.Itoa(i+1)] = value
+	}
+	return replacements
+}
+
+// branchTemplateFile returns the template configured for the current branch,
+// or "" when no pattern matches or there is no branch to match
+func branchTemplateFile(r git.Runner) (string, error) {
+	branch, err := git.GetCurrentBranch(r)
 	if err != nil {
-		return "", fmt.Errorf("failed to open template file: %w", err)
+		log.Debugf("no current branch to select a template: %v", err)
+		return "", nil
 	}
-	defer file.Close()
+	path, ok, err := templateForBranch(branch)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		log.Debugf("branch %s selects template %s", branch, path)
+	}
+	return path, nil
+}
+
+// Result is the outcome of processing a template
+type Result struct {
+	Message string `json:"message"`
+	// Variables holds the value used for each template variable
+	Variables map[string]string `json:"variables"`
+	Branch    string            `json:"branch"`
+}
+
+// loadTemplate parses the template file, falling back to cfg.FallbackTemplate
+// when one is configured and the file is missing or blank
+func loadTemplate(cfg *Config, log *logger) (*template.Template, error) {
+	if cfg.InlineTemplate != "" {
+		return template.ParseString(cfg.InlineTemplate)
+	}
+	if isRemote(cfg.TemplateFile) {
+		if !viper.GetBool("allow-remote") {
+			return nil, fmt.Errorf("%s is a remote template; pass --allow-remote to fetch it", cfg.TemplateFile)
+		}
+		log.Debugf("fetching %s", cfg.TemplateFile)
+		return fetchTemplate(cfg.TemplateFile)
+	}
+	if cfg.FallbackTemplate != "" {
+		data, err := os.ReadFile(cfg.TemplateFile)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && strings.TrimSpace(string(data)) == "") {
+			log.Debugf("%s is missing or empty, using the fallback template", cfg.TemplateFile)
+			return template.ParseString(cfg.FallbackTemplate)
+		}
+	}
+	return template.ParseFile(cfg.TemplateFile)
+}
 
-	t, err := template.Parse(file)
+// processTemplate processes the template file with the given replacements
+func processTemplate(cfg *Config, log *logger) (*Result, error) {
+	t, err := loadTemplate(cfg, log)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		var perr *fs.PathError
+		if errors.As(err, &perr) {
+			err = &templateReadError{err: err}
+		}
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	log.Debugf("parsed %s: %d node(s)", cfg.TemplateFile, len(t.Nodes))
 
-	replacer := template.ReplacerFuncFromMap(cfg.Replacements)
+	replacer := chainReplacers(
+		template.ReplacerFuncFromMap(cfg.Replacements),
+		cfg.Settings,
+		cfg.GitConfig,
+		template.ReplacerFuncFromMap(cfg.Remembered),
+	)
+
+	// Values are recorded as given, only the message is escaped
+	rendering := replacer
+	if cfg.EscapeOutput {
+		rendering = template.MapReplacer(replacer, template.EscapeMarkers)
+	}
 
 	// Use strings.Builder to capture the output
 	var output strings.Builder
-	if err := t.ExecuteTo(&output, replacer); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+	if err := t.ExecuteTo(&output, rendering); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	variables := make(map[string]string)
+	for _, v := range t.Variables() {
+		if _, seen := variables[v.Key]; seen {
+			continue
+		}
+		val, ok := replacer.Get(v.Key)
+		if !ok && v.HasDef {
+			// Computed defaults are reported as rendered, not as their source;
+			// only one in a branch left out of the message can fail to render
+			def, err := v.RenderDefault(replacer)
+			val, ok = def, err == nil
+		}
+		if !ok {
+			continue
+		}
+		variables[v.Key] = val
+		log.Debugf("variable %s = %q", v.Key, variables[v.Key])
+	}
+
+	return &Result{
+		Message:   output.String(),
+		Variables: variables,
+	}, nil
+}
+
+// writeEditMsg writes message into the git message file at path above the
+// comment lines it already holds. A missing file is created.
+func writeEditMsg(path, message string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
 	}
+	return os.WriteFile(path, []byte(commit.MergeEditMsg(string(existing), message)), 0o644)
+}
 
-	return output.String(), nil
+// suppliedValues returns the values of variables the user gave, as replacements
+// or in a remembered run, leaving out those filled in by template defaults,
+// settings or git config so that later changes to them are not overridden
+func suppliedValues(variables, replacements, remembered map[string]string) map[string]string {
+	supplied := make(map[string]string, len(variables))
+	for key := range variables {
+		if v, ok := replacements[key]; ok {
+			supplied[key] = v
+		} else if v, ok := remembered[key]; ok {
+			supplied[key] = v
+		}
+	}
+	return supplied
 }
 
+// formatResult renders the result as the plain message or, with asJSON, as a JSON document
+func formatResult(result *Result, asJSON bool) (string, error) {
+	if !asJSON {
+		return result.Message, nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatErrors renders errs as a bullet list, one error per line
+func formatErrors(errs []error) string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = "  - " + err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listError reports several errors as a bullet list under a title.
+// The errors stay reachable through errors.Is and errors.As.
+type listError struct {
+	title string
+	errs  []error
+}
+
+func (e *listError) Error() string {
+	return e.title + ":\n" + formatErrors(e.errs)
+}
+
+func (e *listError) Unwrap() []error {
+	return e.errs
+}
+
+// messageHints suggests how to fix each kind of message validation failure
+var messageHints = map[commit.Kind]string{
+	commit.KindEmptySubject:     "fill in the first line of the template",
+	commit.KindSubjectTooLong:   "shorten the first line or raise --max-subject-length",
+	commit.KindBadFormat:        `write the subject as "type(scope): description", e.g. "feat(auth): add login"`,
+	commit.KindMissingBlankLine: "leave the second line empty to separate the subject from the body",
+	commit.KindEmptyMessage:     "check that the template and its values render some text",
+	commit.KindStrayMarker:      "fix the malformed token in the template or remove the marker",
+}
+
+// failOnEmpty reports whether blank messages are rejected.
+// --fail-on-empty defaults to on with --execute.
+func failOnEmpty() bool {
+	if viper.IsSet("fail-on-empty") {
+		return viper.GetBool("fail-on-empty")
+	}
+	return viper.GetBool("execute")
+}
+
+// checkMessage validates message against the configured rules
+func checkMessage(message string) error {
+	rules := commit.Rules{
+		NonEmpty:         failOnEmpty(),
+		MaxSubjectLength: viper.GetInt("max-subject-length"),
+		Conventional:     viper.GetBool("conventional"),
+		RequireBlankLine: viper.GetBool("require-blank-line"),
+		NoMarkers:        viper.GetBool("strict-output"),
+	}
+
+	errs := rules.Validate(message)
+	if len(errs) == 0 {
+		return nil
+	}
+	for i, err := range errs {
+		var verr *commit.ValidationError
+		if errors.As(err, &verr) {
+			errs[i] = fmt.Errorf("%w (%s)", err, messageHints[verr.Kind])
+		}
+	}
+	return &listError{title: "invalid commit message", errs: errs}
+}
+
+// gitRunner creates the git runner configured by the persistent flags
+var gitRunner = func() git.Runner {
+	return git.ExecRunner{
+		Dir: viper.GetString("repo"),
+		Bin: viper.GetString("git-bin"),
+	}
+}
+
+// newRunner creates the git runner, logging its commands to log
+// and retrying transient commit and push failures when --retry asks for it
+func newRunner(log *logger) git.Runner {
+	runner := gitRunner()
+	if attempts := viper.GetInt("retry"); attempts > 1 {
+		runner = git.RetryRunner{Runner: runner, Policy: retryPolicy(attempts, log)}
+	}
+	return loggingRunner{
+		Runner:        runner,
+		log:           log,
+		printCommands: viper.GetBool("print-command"),
+	}
+}
+
+// retryPolicy builds the retry policy for the given number of attempts.
+// The retry-patterns config setting replaces git.DefaultRetryPatterns;
+// invalid patterns are skipped with a warning.
+func retryPolicy(attempts int, log *logger) git.RetryPolicy {
+	policy := git.RetryPolicy{
+		MaxAttempts: attempts,
+		Backoff:     viper.GetDuration("retry-backoff"),
+		Patterns:    git.DefaultRetryPatterns,
+	}
+	if exprs := viper.GetStringSlice("retry-patterns"); len(exprs) > 0 {
+		policy.Patterns = nil
+		for _, expr := range exprs {
+			pattern, err := regexp.Compile(expr)
+			if err != nil {
+				fmt.Fprintf(log.err, "Warning: ignoring invalid retry pattern %q: %v\n", expr, err)
+				continue
+			}
+			policy.Patterns = append(policy.Patterns, pattern)
+		}
+	}
+	return policy
+}
+
+// log is the logger configured by the verbose and quiet flags
+var log = newLogger(os.Stdout, os.Stderr, false, false)
+
 var rootCmd = &cobra.Command{
 	Use:   "tcommit",
 	Short: "Template-based commit message generator",
@@ -69,35 +363,212 @@ It supports variable substitution and conditional formatting.
 
 You can provide replacements in two ways:
 	1. Using --replace flag: --replace key=value
+	2. Using --replace-json with a JSON object: --replace-json '{"type":"feat"}'
 
 Examples:
 	tcommit template.txt --replace type=feat --replace scope=auth
-	tcommit template.txt --replace type=feat --replace scope=auth --execute`,
-	Args: cobra.ExactArgs(1),
+	tcommit template.txt --replace type=feat --replace scope=auth --execute
+	tcommit template.txt -- feat auth "add login"
+	tcommit --inline "feat: {{.desc}}" --replace desc="add login"
+	tcommit --amend --no-edit
+	tcommit --amend --from-head
+
+Values after "--" fill the positional variables {{.1}}, {{.2}}, ...
+Replacements given with --replace take precedence over them.
+Variables given no value fall back to the entries of the vars config setting
+of the same name, e.g. {{.author.name}} to vars.author.name, and {{.author}} and {{.email}} to git config user.name and user.email.
+
+Values are inserted as given: one holding {{ or }} makes --strict-output reject
+the message, unless --escape-output escapes them as {\{ and }\}.
+
+The exit code tells scripts what failed: 0 on success, 2 for a template that
+cannot be parsed or executed, 3 for a value or message rejected by validation,
+4 for a git failure and 1 for anything else.
+
+Defaults for every flag, the template path (template) and the editor key bindings
+(keys) can be set in tcommit.yaml, read from --config or searched for in the
+working directory and $XDG_CONFIG_HOME/tcommit. Flags override the file.
+
+Choice sets shared by several templates can be named under enums and
+referenced as {{.type:@enum:committype}}:
+
+	enums:
+	  committype: [feat, fix, docs, chore]
+
+Choices can also be computed: {{.scope:@cmd:dirs}} offers the top-level
+directories of the repository, {{.author:@cmd:authors}} the recent commit authors
+and {{.scope:@cmd:scopes}} the scopes of recent conventional commits.
+
+Without a template argument, branch-templates picks the template of the first
+pattern matching the current branch, falling back to template:
+
+	branch-templates:
+	  - pattern: ^(feature|feat)/
+	    template: templates/feature.tmpl
+	  - pattern: ^hotfix/
+	    template: templates/hotfix.tmpl`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		templateArgs, _ := splitArgs(cmd, args)
+		if viper.GetString("inline") != "" {
+			if len(templateArgs) > 0 {
+				return errors.New("--inline cannot be used with a template file")
+			}
+			if viper.GetBool("from-head") {
+				return errors.New("--inline cannot be used with --from-head")
+			}
+			return nil
+		}
+		if viper.GetBool("from-head") {
+			if len(templateArgs) > 0 {
+				return errors.New("--from-head cannot be used with a template file")
+			}
+			return nil
+		}
+		// Keeping the previous message does not need a template,
+		// and the config file may name a default one or one per branch
+		if viper.GetBool("no-edit") || viper.GetString("template") != "" || viper.IsSet("branch-templates") {
+			return cobra.MaximumNArgs(1)(cmd, templateArgs)
+		}
+		return cobra.ExactArgs(1)(cmd, templateArgs)
+	},
+	ValidArgsFunction: completion.SingleTemplate,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		replaceFlags := viper.GetStringSlice("replace")
-		replacements, err := parseReplacements(replaceFlags)
+		replacements, err := parseReplacementsJSON(viper.GetString("replace-json"))
+		if err != nil {
+			return err
+		}
+		replaceFlags, err := parseReplacements(viper.GetStringSlice("replace"))
 		if err != nil {
 			return fmt.Errorf("invalid replacements: %w", err)
 		}
-		viper.Set("replacements", replacements)
+		// Individual --replace flags override the JSON object
+		viper.Set("replacements", template.MergeMaps(replacements, replaceFlags))
 		viper.Set("message", "")
+		viper.Set("canceled", false)
+		registerEnums()
+
+		log = newLogger(cmd.OutOrStdout(), cmd.ErrOrStderr(),
+			viper.GetBool("verbose"), viper.GetBool("quiet"))
+		registerProviders(newRunner(log), viper.GetString("repo"))
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool("no-edit") {
+			// The commit keeps its message, so there is nothing to render
+			return nil
+		}
+
+		templateArgs, positional := splitArgs(cmd, args)
+		replacements := template.MergeMaps(positionalReplacements(positional), viper.GetStringMapString("replacements"))
+
+		inline := viper.GetString("inline")
+		if viper.GetBool("from-head") {
+			if !viper.GetBool("amend") {
+				return errors.New("--from-head requires --amend")
+			}
+			// The previous message is the template, so its tokens are still filled in
+			message, err := git.GetLastCommitMessage(newRunner(log))
+			if err != nil {
+				return fmt.Errorf("failed to read the last commit message: %w", err)
+			}
+			inline = message
+		}
+		templateFile := viper.GetString("template")
+		if inline != "" {
+			templateFile = ""
+			if viper.GetBool("remember") {
+				return errors.New("--remember needs a template file to remember values for")
+			}
+		} else if len(templateArgs) > 0 {
+			templateFile = templateArgs[0]
+		} else if viper.IsSet("branch-templates") {
+			path, err := branchTemplateFile(newRunner(log))
+			if err != nil {
+				return err
+			}
+			if path != "" {
+				templateFile = path
+			}
+		}
+		if templateFile == "" && inline == "" {
+			return errors.New("no template given and none configured for the current branch")
+		}
+
 		cfg := &Config{
-			Replacements: viper.GetStringMapString("replacements"),
-			TemplateFile: args[0],
+			Replacements:     replacements,
+			TemplateFile:     templateFile,
+			InlineTemplate:   inline,
+			FallbackTemplate: viper.GetString("fallback-template"),
+			Settings:         ViperReplacer(configVars(viper.GetViper())),
+			GitConfig:        GitConfigReplacer(newRunner(log)),
+			EscapeOutput:     viper.GetBool("escape-output"),
+		}
+
+		var state history.State
+		var statePath string
+		if viper.GetBool("remember") {
+			var err error
+			state, statePath, err = history.Open(viper.GetString("state-file"))
+			if err != nil {
+				return fmt.Errorf("failed to load remembered values: %w", err)
+			}
+			cfg.Remembered = state.Values(templateFile)
+		}
+
+		result, err := processTemplate(cfg, log)
+		if err != nil {
+			return err
 		}
 
-		message, err := processTemplate(cfg)
+		if state != nil {
+			state.Remember(templateFile, suppliedValues(result.Variables, replacements, cfg.Remembered))
+			if err := state.Save(statePath); err != nil {
+				return fmt.Errorf("failed to remember values: %w", err)
+			}
+		}
+
+		if viper.GetBool("edit") {
+			edited, err := editMessage(cmd, editorCommand(newRunner(log)), result.Message)
+			if err != nil {
+				return err
+			}
+			result.Message = edited
+		}
+		if width := viper.GetInt("wrap"); width > 0 {
+			result.Message = commit.WrapBody(result.Message, width)
+		}
+
+		asJSON := viper.GetBool("json")
+		if asJSON {
+			// Outside of a repository there is simply no branch to report
+			result.Branch, _ = git.GetCurrentBranch(newRunner(log))
+		}
+
+		if err := checkMessage(result.Message); err != nil {
+			return err
+		}
+
+		// Hand the message to the commit step
+		viper.Set("message", result.Message)
+
+		message, err := formatResult(result, asJSON)
 		if err != nil {
 			return err
 		}
 
-		// Print the message
-		fmt.Println(message)
+		// Write the message to the output file, merge it into the message file or print it
+		if output := viper.GetString("output"); output != "" {
+			if err := os.WriteFile(output, []byte(message), 0o644); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+		} else if msgFile := viper.GetString("amend-msg-file"); msgFile != "" {
+			if err := writeEditMsg(msgFile, message); err != nil {
+				return fmt.Errorf("failed to write message file: %w", err)
+			}
+		} else {
+			log.Println(message)
+		}
 
 		// Execute git commit if requested
 		if cfg.ExecuteGit {
@@ -107,45 +578,254 @@ Examples:
 		return nil
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
-		if !viper.GetBool("execute") {
+		noEdit := viper.GetBool("no-edit")
+		// A subcommand such as bubble sets canceled when the user backed out
+		if (!viper.GetBool("execute") && !noEdit) || viper.GetBool("canceled") {
 			return nil
 		}
-		if err := git.ValidateGitState(); err != nil {
-			return fmt.Errorf("git validation failed: %w", err)
+		if noEdit && len(viper.GetStringSlice("co-author")) > 0 {
+			return fmt.Errorf("--co-author cannot be used with --no-edit")
+		}
+		if !noEdit {
+			if err := checkMessage(viper.GetString("message")); err != nil {
+				return err
+			}
+		}
+		var trailers []string
+		for _, coAuthor := range viper.GetStringSlice("co-author") {
+			trailers = append(trailers, commit.Trailer(commit.CoAuthorKey, coAuthor))
+		}
+
+		opts := git.CommitOptions{
+			Message:  commit.AppendTrailers(viper.GetString("message"), trailers),
+			Amend:    viper.GetBool("amend"),
+			NoEdit:   noEdit,
+			Author:   viper.GetString("author"),
+			Date:     viper.GetString("date"),
+			NoVerify: viper.GetBool("no-verify"),
+			Validation: git.ValidationConfig{
+				AllowUnstaged: viper.GetBool("allow-unstaged"),
+				AllowDetached: viper.GetBool("allow-detached"),
+			},
 		}
 
-		if err := git.Commit(viper.GetString("message")); err != nil {
+		if viper.GetBool("normalize") {
+			opts.Message = commit.Normalize(opts.Message)
+		}
+
+		runner := newRunner(log)
+
+		if paths := viper.GetStringSlice("stage"); len(paths) > 0 {
+			if err := git.StageFiles(runner, paths...); err != nil {
+				return fmt.Errorf("failed to stage files: %w", err)
+			}
+		}
+
+		if errs := git.ValidateCommitAll(runner, opts); len(errs) > 0 {
+			return &listError{title: "git validation failed", errs: errs}
+		}
+
+		if err := git.Commit(runner, opts); err != nil {
 			return fmt.Errorf("failed to execute git commit: %w", err)
 		}
 
+		if viper.GetBool("push") {
+			if err := git.Push(runner, "", ""); err != nil {
+				return fmt.Errorf("failed to execute git push: %w", err)
+			}
+		}
+
 		return nil
 	},
 }
 
-// Execute runs the root command
+// Execute runs the root command and exits with the code classifying its error, see exitCode
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(rootCmd.ErrOrStderr(), "Error: %v\n", err)
+		exit(exitCode(err))
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringSliceP("replace", "r", []string{},
+	cobra.OnInitialize(func() {
+		if err := loadConfig(viper.GetString("config")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	})
+
+	rootCmd.PersistentFlags().String("config", "",
+		"Config file (defaults to tcommit.yaml in the working directory or $XDG_CONFIG_HOME/tcommit)")
+
+	rootCmd.PersistentFlags().StringSliceP("replace", "r", []string{},
 		"Replacements in format key=value (can be specified multiple times)")
 
+	rootCmd.PersistentFlags().String("replace-json", "",
+		`Replacements as a JSON object of strings, e.g. '{"type":"feat"}' (--replace takes precedence)`)
+
 	rootCmd.PersistentFlags().BoolP("execute", "e", false,
 		"Execute git commit with the generated message")
 
-	if err := viper.BindPFlag("replace", rootCmd.Flags().Lookup("replace")); err != nil {
+	rootCmd.PersistentFlags().String("fallback-template", "",
+		"Template text rendered when the template file is missing or empty")
+
+	rootCmd.PersistentFlags().Bool("remember", false,
+		"Prefill the values last given for the template and remember the new ones (template defaults and config values are not remembered)")
+
+	rootCmd.PersistentFlags().String("state-file", "",
+		"File remembering the last used values (defaults to $XDG_CONFIG_HOME/tcommit/state.json)")
+
+	rootCmd.PersistentFlags().StringArray("stage", []string{},
+		"Stage the given pathspec before committing (requires --execute, can be specified multiple times)")
+
+	rootCmd.PersistentFlags().Bool("push", false,
+		"Push the current branch to origin after committing (requires --execute)")
+
+	rootCmd.PersistentFlags().Int("retry", 1,
+		"Try git commit and push up to this many times on transient failures such as a held index.lock "+
+			"(the retry-patterns config setting selects which failures)")
+
+	rootCmd.PersistentFlags().Duration("retry-backoff", time.Second,
+		"Wait before the first retry, doubled before each further one")
+
+	rootCmd.PersistentFlags().Bool("amend", false,
+		"Amend the last commit instead of creating a new one (requires --execute)")
+
+	rootCmd.PersistentFlags().Bool("no-edit", false,
+		"With --amend, commit the staged changes keeping the previous message and skip the template")
+
+	rootCmd.PersistentFlags().StringSlice("co-author", []string{},
+		`Add a Co-authored-by trailer as "Name <email>" (can be specified multiple times)`)
+
+	rootCmd.PersistentFlags().String("author", "",
+		`Override the commit author as "Name <email>"`)
+
+	rootCmd.PersistentFlags().String("date", "",
+		"Override the author date of the commit")
+
+	rootCmd.PersistentFlags().Int("wrap", 0,
+		"Wrap the body lines of the message at the given column, e.g. 72 (0 disables)")
+
+	rootCmd.PersistentFlags().Bool("no-verify", false,
+		"Skip the pre-commit and commit-msg hooks when committing (requires --execute)")
+
+	rootCmd.Flags().StringP("output", "o", "",
+		"Write the generated message to a file instead of printing it")
+
+	if err := viper.BindPFlag("output", rootCmd.Flags().Lookup("output")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+
+	rootCmd.Flags().String("amend-msg-file", "",
+		"Write the generated message into a git message file such as .git/COMMIT_EDITMSG, keeping its # comment lines")
+
+	if err := viper.BindPFlag("amend-msg-file", rootCmd.Flags().Lookup("amend-msg-file")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+	rootCmd.MarkFlagsMutuallyExclusive("output", "amend-msg-file")
+
+	rootCmd.Flags().Bool("json", false,
+		"Print the message, variables and current branch as JSON")
+
+	if err := viper.BindPFlag("json", rootCmd.Flags().Lookup("json")); err != nil {
 		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := viper.BindPFlag("execute", rootCmd.PersistentFlags().Lookup("execute")); err != nil {
+	rootCmd.Flags().Bool("edit", false,
+		"Open the rendered message in $EDITOR (or git config core.editor) before using it")
+
+	if err := viper.BindPFlag("edit", rootCmd.Flags().Lookup("edit")); err != nil {
 		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
 		os.Exit(1)
 	}
 
+	rootCmd.Flags().String("inline", "",
+		`Render the given template string instead of a template file, e.g. --inline "feat: {{.desc}}"`)
+
+	if err := viper.BindPFlag("inline", rootCmd.Flags().Lookup("inline")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+
+	rootCmd.Flags().Bool("from-head", false,
+		"With --amend, use the message of the last commit as the template")
+
+	if err := viper.BindPFlag("from-head", rootCmd.Flags().Lookup("from-head")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flag: %v\n", err)
+		os.Exit(1)
+	}
+
+	rootCmd.PersistentFlags().Bool("allow-remote", false,
+		"Allow the template to be an http(s):// URL, fetched and cached by its ETag")
+
+	rootCmd.PersistentFlags().Int("max-subject-length", 0,
+		"Reject messages whose first line is longer than this many characters (0 disables the check)")
+
+	rootCmd.PersistentFlags().Bool("conventional", false,
+		`Require a Conventional Commits subject such as "feat(scope): description"`)
+
+	rootCmd.PersistentFlags().Bool("require-blank-line", false,
+		"Require a blank line between the subject and the body")
+
+	rootCmd.PersistentFlags().Bool("strict-output", false,
+		"Reject messages still holding a {{ or }} marker, e.g. from a malformed token")
+
+	rootCmd.PersistentFlags().Bool("escape-output", false,
+		`Escape {{ and }} in values as {\{ and }\} so they cannot pass for template markers`)
+
+	rootCmd.PersistentFlags().Bool("normalize", true,
+		"Collapse repeated blank lines and end the committed message with a single newline")
+
+	rootCmd.PersistentFlags().Bool("fail-on-empty", false,
+		"Reject messages that are empty or only whitespace (on by default with --execute)")
+
+	rootCmd.PersistentFlags().Bool("allow-unstaged", false,
+		"Commit even if the work tree has unstaged changes (requires --execute)")
+
+	rootCmd.PersistentFlags().Bool("allow-detached", false,
+		"Commit even on a detached HEAD (requires --execute)")
+
+	rootCmd.PersistentFlags().Bool("print-command", false,
+		"Print the git commit and push commands to stderr before running them")
+
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false,
+		"Log parsing details, resolved variables and git commands to stderr")
+
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false,
+		"Do not print the generated message (errors are still reported)")
+
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
+	rootCmd.PersistentFlags().String("repo", "",
+		"Path of the git repository to commit to (defaults to the current directory)")
+
+	rootCmd.PersistentFlags().String("git-bin", "",
+		"Path of the git executable (defaults to $TCOMMIT_GIT, then git from PATH)")
+
+	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := viper.BindEnv("git-bin", "TCOMMIT_GIT"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error binding env: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Replace cobra's default completion command with one completing template files
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
 	rootCmd.AddCommand(bubble.GetCommand())
+	rootCmd.AddCommand(lint.GetCommand())
+	rootCmd.AddCommand(check.GetCommand())
+	rootCmd.AddCommand(listvars.GetCommand())
+	rootCmd.AddCommand(hook.GetCommand())
+	rootCmd.AddCommand(batch.GetCommand())
+	rootCmd.AddCommand(preview.GetCommand())
+	rootCmd.AddCommand(initialize.GetCommand())
+	rootCmd.AddCommand(completion.GetCommand())
 }
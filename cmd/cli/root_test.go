@@ -0,0 +1,627 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhiCu/TCommit/internal/core/commit"
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/WhiCu/TCommit/internal/core/history"
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTemplate writes content to a template file in a temp dir and returns its path
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "commit.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestProcessTemplate(t *testing.T) {
+	result, err := processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.type:feat|@fix}}({{.scope:@core}}): {{.desc}}"),
+		Replacements: map[string]string{"type": "feat", "desc": "add login", "unused": "x"},
+	}, newLogger(io.Discard, io.Discard, false, false))
+	require.NoError(t, err)
+
+	assert.Equal(t, "feat(core): add login", result.Message)
+	assert.Equal(t, map[string]string{"type": "feat", "scope": "core", "desc": "add login"}, result.Variables)
+}
+
+func TestProcessTemplateComputedDefault(t *testing.T) {
+	result, err := processTemplate(&Config{
+		TemplateFile: writeTemplate(t, "{{.type}}: {{.title:@{{.type}} stuff}}"),
+		Replacements: map[string]string{"type": "fix"},
+	}, newLogger(io.Discard, io.Discard, false, false))
+	require.NoError(t, err)
+
+	assert.Equal(t, "fix: fix stuff", result.Message)
+	assert.Equal(t, map[string]string{"type": "fix", "title": "fix stuff"}, result.Variables)
+}
+
+func TestFormatResultJSON(t *testing.T) {
+	result := &Result{
+		Message:   "feat(core): add login",
+		Variables: map[string]string{"type": "feat", "scope": "core"},
+		Branch:    "main",
+	}
+
+	plain, err := formatResult(result, false)
+	require.NoError(t, err)
+	assert.Equal(t, "feat(core): add login", plain)
+
+	out, err := formatResult(result, true)
+	require.NoError(t, err)
+
+	var decoded Result
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, *result, decoded)
+}
+
+func TestFormatErrors(t *testing.T) {
+	errs := []error{
+		errors.New("no staged changes to commit"),
+		errors.New("detached HEAD state"),
+	}
+	assert.Equal(t, "  - no staged changes to commit\n  - detached HEAD state", formatErrors(errs))
+}
+
+func TestProcessTemplateFallback(t *testing.T) {
+	log := newLogger(io.Discard, io.Discard, false, false)
+	fallback := "{{.type:@chore}}: {{.desc}}"
+	replacements := map[string]string{"desc": "update deps"}
+
+	for name, path := range map[string]string{
+		"Empty file":   writeTemplate(t, " \n"),
+		"Missing file": filepath.Join(t.TempDir(), "missing.tmpl"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := processTemplate(&Config{
+				TemplateFile:     path,
+				Replacements:     replacements,
+				FallbackTemplate: fallback,
+			}, log)
+			require.NoError(t, err)
+			assert.Equal(t, "chore: update deps", result.Message)
+		})
+	}
+
+	// A template with content wins over the fallback
+	result, err := processTemplate(&Config{
+		TemplateFile:     writeTemplate(t, "docs: {{.desc}}"),
+		Replacements:     replacements,
+		FallbackTemplate: fallback,
+	}, log)
+	require.NoError(t, err)
+	assert.Equal(t, "docs: update deps", result.Message)
+
+	// Without a fallback a missing file is still an error
+	_, err = processTemplate(&Config{TemplateFile: filepath.Join(t.TempDir(), "missing.tmpl")}, log)
+	assert.Error(t, err)
+}
+
+// resetFlags restores the root command flags a test sets once it finishes
+func resetFlags(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.PersistentFlags().Set("execute", "false"))
+		replace := rootCmd.PersistentFlags().Lookup("replace").Value.(interface{ Replace([]string) error })
+		require.NoError(t, replace.Replace(nil))
+		require.NoError(t, rootCmd.PersistentFlags().Set("replace-json", ""))
+		// The flag set keeps the position of a "--" across parses
+		rootCmd.Flags().Init(rootCmd.Name(), pflag.ContinueOnError)
+		rootCmd.SetArgs(nil)
+		rootCmd.SetIn(nil)
+		rootCmd.SetOut(nil)
+	})
+}
+
+// commitRecorder is a git runner in a clean repository state that records the committed message
+type commitRecorder struct {
+	message string
+	calls   []string
+}
+
+func (c *commitRecorder) Run(args ...string) (string, error) {
+	c.calls = append(c.calls, strings.Join(args, " "))
+	switch strings.Join(args, " ") {
+	case "rev-parse --is-inside-work-tree":
+		return "true", nil
+	case "diff --cached --name-only":
+		return "main.go", nil
+	case "rev-parse --abbrev-ref HEAD":
+		return "main", nil
+	}
+	if args[0] == "commit" && args[1] == "-F" {
+		data, err := os.ReadFile(args[2])
+		if err != nil {
+			return "", err
+		}
+		c.message = string(data)
+	}
+	return "", nil
+}
+
+func TestBubbleCommitsRenderedMessage(t *testing.T) {
+	recorder := &commitRecorder{}
+	restore := gitRunner
+	gitRunner = func() git.Runner { return recorder }
+	t.Cleanup(func() { gitRunner = restore })
+	resetFlags(t)
+
+	path := writeTemplate(t, "{{.type:@feat}}: {{.desc}}\n\nFirst line of the body\nsecond line")
+
+	// Move to desc, focus it, type, unfocus and confirm
+	rootCmd.SetIn(strings.NewReader("w\tadd login\t\r"))
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{"bubble", path, "--no-stage", "--execute"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, "feat: add login\n\nFirst line of the body\nsecond line\n", recorder.message)
+}
+
+func TestBubbleQuitCommitsNothing(t *testing.T) {
+	recorder := &commitRecorder{}
+	restore := gitRunner
+	gitRunner = func() git.Runner { return recorder }
+	t.Cleanup(func() { gitRunner = restore })
+	resetFlags(t)
+
+	// Every value has a default, so rendering on quit would succeed
+	path := writeTemplate(t, "{{.type:@feat}}: {{.desc:@wip}}")
+
+	rootCmd.SetIn(strings.NewReader("q"))
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{"bubble", path, "--no-stage", "--execute"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Empty(t, recorder.message)
+	assert.NotContains(t, recorder.calls, "commit")
+}
+
+func TestRootCommitsRenderedMessage(t *testing.T) {
+	recorder := &commitRecorder{}
+	restore := gitRunner
+	gitRunner = func() git.Runner { return recorder }
+	t.Cleanup(func() { gitRunner = restore })
+	resetFlags(t)
+
+	path := writeTemplate(t, "{{.type:@feat}}: {{.desc}}\n\n\n\nBody")
+
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{path, "--replace", "desc=add login", "--execute"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, "feat: add login\n\nBody\n", recorder.message)
+
+	// Normalization can be turned off
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("normalize", "true")) })
+	rootCmd.SetArgs([]string{path, "--replace", "desc=add login", "--execute", "--normalize=false"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, "feat: add login\n\n\n\nBody", recorder.message)
+}
+
+func TestPositionalArgs(t *testing.T) {
+	resetFlags(t)
+	path := writeTemplate(t, "{{.1}}({{.2}}): {{.3}}")
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "--replace", "2=core", "--", "feat", "auth", "add login"})
+	require.NoError(t, rootCmd.Execute())
+
+	// Named replacements override positional values
+	assert.Equal(t, "feat(core): add login\n", out.String())
+}
+
+func TestPositionalArgsWithoutTemplateArg(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.Flags().Set("inline", ""))
+		viper.Set("template", nil)
+	})
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"--inline", "{{.1}}-{{.2}}", "--", "x", "y"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "x-y\n", out.String())
+
+	// The values after the dash go to the configured template
+	require.NoError(t, rootCmd.Flags().Set("inline", ""))
+	viper.Set("template", writeTemplate(t, "{{.1}}: {{.2}}"))
+	out.Reset()
+	rootCmd.SetArgs([]string{"--", "feat", "add login"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: add login\n", out.String())
+}
+
+func TestSplitArgs(t *testing.T) {
+	cmd := &cobra.Command{Use: "test", Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, cmd.ParseFlags([]string{"template.txt", "--", "feat", "auth"}))
+
+	templateArgs, positional := splitArgs(cmd, cmd.Flags().Args())
+	assert.Equal(t, []string{"template.txt"}, templateArgs)
+	assert.Equal(t, []string{"feat", "auth"}, positional)
+
+	assert.Equal(t, map[string]string{"1": "feat", "2": "auth"}, positionalReplacements(positional))
+
+	// Without an argument before the dash there is no template argument
+	cmd = &cobra.Command{Use: "test", Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, cmd.ParseFlags([]string{"--", "feat", "auth"}))
+	templateArgs, positional = splitArgs(cmd, cmd.Flags().Args())
+	assert.Empty(t, templateArgs)
+	assert.Equal(t, []string{"feat", "auth"}, positional)
+}
+
+func TestCheckMessage(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("max-subject-length", nil)
+		viper.Set("conventional", nil)
+	})
+
+	require.NoError(t, checkMessage("Added the login page"))
+
+	viper.Set("max-subject-length", 10)
+	viper.Set("conventional", true)
+	err := checkMessage("Added the login page")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, commit.ErrSubjectTooLong)
+	assert.ErrorIs(t, err, commit.ErrBadFormat)
+	assert.Contains(t, err.Error(), "raise --max-subject-length")
+
+	var verr *commit.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, commit.KindSubjectTooLong, verr.Kind)
+}
+
+func TestRememberValues(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.PersistentFlags().Set("remember", "false"))
+		require.NoError(t, rootCmd.PersistentFlags().Set("state-file", ""))
+	})
+	path := writeTemplate(t, "{{.type:@feat}}({{.scope}}): {{.desc}}")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "--remember", "--state-file", stateFile,
+		"-r", "type=fix", "-r", "scope=core", "-r", "desc=first"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix(core): first\n", out.String())
+
+	// The remembered values prefill what is not given again
+	out.Reset()
+	replace := rootCmd.PersistentFlags().Lookup("replace").Value.(interface{ Replace([]string) error })
+	require.NoError(t, replace.Replace(nil))
+	rootCmd.SetArgs([]string{path, "--remember", "--state-file", stateFile, "-r", "desc=second"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix(core): second\n", out.String())
+
+	state, err := history.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "fix", "scope": "core", "desc": "second"}, state.Values(path))
+}
+
+func TestRememberSuppliedValuesOnly(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.PersistentFlags().Set("remember", "false"))
+		require.NoError(t, rootCmd.PersistentFlags().Set("state-file", ""))
+	})
+	path := writeTemplate(t, "{{.type:@feat}}: {{.desc}}")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "--remember", "--state-file", stateFile, "-r", "desc=first"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: first\n", out.String())
+
+	state, err := history.Load(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"desc": "first"}, state.Values(path), "the default is not remembered")
+
+	// A changed default applies to the next run
+	require.NoError(t, os.WriteFile(path, []byte("{{.type:@fix}}: {{.desc}}"), 0o644))
+	out.Reset()
+	replace := rootCmd.PersistentFlags().Lookup("replace").Value.(interface{ Replace([]string) error })
+	require.NoError(t, replace.Replace(nil))
+	rootCmd.SetArgs([]string{path, "--remember", "--state-file", stateFile})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix: first\n", out.String())
+}
+
+func TestReplaceJSON(t *testing.T) {
+	resetFlags(t)
+	path := writeTemplate(t, "{{.type}}({{.scope}}): {{.desc}}")
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "--replace-json", `{"type":"feat","scope":"auth","desc":"add login"}`, "-r", "scope=core"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat(core): add login\n", out.String())
+}
+
+func TestParseReplacementsJSON(t *testing.T) {
+	replacements, err := parseReplacementsJSON("")
+	require.NoError(t, err)
+	assert.Empty(t, replacements)
+
+	replacements, err = parseReplacementsJSON(`{"type": "feat"}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "feat"}, replacements)
+
+	for _, data := range []string{`{"type":`, `["feat"]`, `{"issue": 42}`} {
+		_, err = parseReplacementsJSON(data)
+		assert.ErrorContains(t, err, "invalid --replace-json", data)
+	}
+}
+
+func TestStageFlag(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	dir := t.TempDir()
+	gitIn := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	gitIn("init", "-q", "-b", "main")
+	gitIn("config", "user.name", "Test")
+	gitIn("config", "user.email", "test@example.com")
+	gitIn("commit", "-q", "--allow-empty", "-m", "initial")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "login.go"), []byte("package login"), 0o644))
+
+	restore := gitRunner
+	gitRunner = func() git.Runner { return git.ExecRunner{Dir: dir} }
+	t.Cleanup(func() { gitRunner = restore })
+	resetFlags(t)
+	t.Cleanup(func() {
+		stage := rootCmd.PersistentFlags().Lookup("stage").Value.(interface{ Replace([]string) error })
+		require.NoError(t, stage.Replace(nil))
+	})
+
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{writeTemplate(t, "feat: {{.desc}}"), "-r", "desc=add login",
+		"--stage", "login.go", "--execute"})
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, "feat: add login\n\n", gitIn("log", "-1", "--format=%B"))
+	assert.Equal(t, "login.go\n", gitIn("ls-tree", "--name-only", "HEAD"))
+}
+
+func TestFailOnEmpty(t *testing.T) {
+	recorder := &commitRecorder{}
+	restore := gitRunner
+	gitRunner = func() git.Runner { return recorder }
+	t.Cleanup(func() { gitRunner = restore })
+	resetFlags(t)
+
+	path := writeTemplate(t, "{{.desc:@}}  \n\t\n")
+
+	// Plain rendering allows an empty message
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{path})
+	require.NoError(t, rootCmd.Execute())
+
+	// but committing it does not
+	rootCmd.SetArgs([]string{path, "--execute"})
+	err := rootCmd.Execute()
+	require.ErrorIs(t, err, commit.ErrEmptyMessage)
+	assert.Empty(t, recorder.message)
+	assert.NotContains(t, recorder.calls, "commit")
+
+	// The check can be asked for without committing
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("fail-on-empty", "false")) })
+	rootCmd.SetArgs([]string{path, "--execute=false", "--fail-on-empty"})
+	err = rootCmd.Execute()
+	require.ErrorIs(t, err, commit.ErrEmptyMessage)
+	assert.Contains(t, err.Error(), "render some text")
+}
+
+func TestWrapFlag(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("wrap", "0")) })
+	path := writeTemplate(t, "feat: {{.desc}}\n\n{{.body}}")
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "--wrap", "20", "-r", "desc=add a rather long subject line", "-r", "body=the body is wrapped at twenty columns"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: add a rather long subject line\n\nthe body is wrapped\nat twenty columns\n", out.String())
+}
+
+func TestStrictOutput(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.PersistentFlags().Set("strict-output", "false")) })
+	rootCmd.SetOut(io.Discard)
+
+	// An unclosed marker is taken as text
+	stray := writeTemplate(t, "feat: {{.desc}} {{ oops")
+	rootCmd.SetArgs([]string{stray, "-r", "desc=add login", "--strict-output"})
+	err := rootCmd.Execute()
+	require.ErrorIs(t, err, commit.ErrStrayMarker)
+	assert.Contains(t, err.Error(), "column 17")
+
+	clean := writeTemplate(t, "feat: {{.desc}}")
+	rootCmd.SetArgs([]string{clean, "-r", "desc=add login", "--strict-output"})
+	assert.NoError(t, rootCmd.Execute())
+}
+
+func TestEscapeOutput(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.PersistentFlags().Set("strict-output", "false"))
+		require.NoError(t, rootCmd.PersistentFlags().Set("escape-output", "false"))
+	})
+	path := writeTemplate(t, "feat: {{.desc}}")
+
+	// Values are inserted as given
+	for _, value := range []string{"desc=render }} literally", "desc=render {{ literally"} {
+		rootCmd.SetOut(io.Discard)
+		rootCmd.SetArgs([]string{path, "-r", value, "--strict-output"})
+		assert.ErrorIs(t, rootCmd.Execute(), commit.ErrStrayMarker, value)
+	}
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{path, "-r", "desc=map {{a}} to }}}", "--strict-output", "--escape-output"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: map {\\{a}\\} to }\\}\\}\n", out.String())
+}
+
+// statusError chooses its exit status
+type statusError int
+
+func (e statusError) Error() string   { return "status" }
+func (e statusError) ExitStatus() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 1, exitCode(errors.New("failed")))
+	assert.Equal(t, 5, exitCode(fmt.Errorf("wrapped: %w", statusError(5))))
+}
+
+func TestTemplateDirectory(t *testing.T) {
+	resetFlags(t)
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetArgs([]string{t.TempDir()})
+
+	err := rootCmd.Execute()
+	require.ErrorIs(t, err, template.ErrIsDirectory)
+	assert.ErrorContains(t, err, "expected a file, got a directory")
+}
+
+func TestInlineTemplate(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { require.NoError(t, rootCmd.Flags().Set("inline", "")) })
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"--inline", "feat: {{.desc}}", "-r", "desc=inline"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "feat: inline\n", out.String())
+
+	// The template file argument is exclusive with --inline
+	path := writeTemplate(t, "fix: {{.desc}}")
+	rootCmd.SetArgs([]string{path, "--inline", "feat: {{.desc}}", "-r", "desc=x"})
+	assert.ErrorContains(t, rootCmd.Execute(), "--inline cannot be used with a template file")
+
+	// and still works on its own
+	require.NoError(t, rootCmd.Flags().Set("inline", ""))
+	out.Reset()
+	rootCmd.SetArgs([]string{path, "-r", "desc=file"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix: file\n", out.String())
+}
+
+func TestRetryPolicy(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		viper.Set("retry-patterns", nil)
+		require.NoError(t, rootCmd.PersistentFlags().Set("retry", "1"))
+	})
+
+	var warnings strings.Builder
+	log := newLogger(io.Discard, &warnings, false, false)
+	assert.Equal(t, git.DefaultRetryPatterns, retryPolicy(3, log).Patterns)
+
+	viper.Set("retry-patterns", []string{"busy", "("})
+	policy := retryPolicy(3, log)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, time.Second, policy.Backoff)
+	require.Len(t, policy.Patterns, 1)
+	assert.Equal(t, "busy", policy.Patterns[0].String())
+	assert.Contains(t, warnings.String(), `invalid retry pattern "("`)
+
+	// Retries only wrap the runner when asked for
+	assert.IsType(t, git.ExecRunner{}, newRunner(log).(loggingRunner).Runner)
+	require.NoError(t, rootCmd.PersistentFlags().Set("retry", "3"))
+	assert.IsType(t, git.RetryRunner{}, newRunner(log).(loggingRunner).Runner)
+}
+
+func TestAmendMsgFile(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		// Unset the flags so that their exclusive group passes in later tests
+		for _, name := range []string{"amend-msg-file", "output"} {
+			require.NoError(t, rootCmd.Flags().Set(name, ""))
+			rootCmd.Flags().Lookup(name).Changed = false
+		}
+	})
+
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	comments := "# Please enter the commit message for your changes. Lines starting\n" +
+		"# with '#' will be ignored, and an empty message aborts the commit.\n#\n# On branch main\n"
+	require.NoError(t, os.WriteFile(msgFile, []byte("\n"+comments), 0o644))
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{writeTemplate(t, "feat: {{.desc}}\n\nBody"), "-r", "desc=add login", "--amend-msg-file", msgFile})
+	require.NoError(t, rootCmd.Execute())
+	assert.Empty(t, out.String(), "the message goes to the file only")
+
+	data, err := os.ReadFile(msgFile)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login\n\nBody\n\n"+comments, string(data))
+
+	rootCmd.SetArgs([]string{writeTemplate(t, "fix"), "--amend-msg-file", msgFile, "--output", msgFile})
+	assert.ErrorContains(t, rootCmd.Execute(), "none of the others can be")
+}
+
+// headRunner is a git runner whose last commit has the given message
+type headRunner string
+
+func (h headRunner) Run(args ...string) (string, error) {
+	if strings.Join(args, " ") == "log -1 --format=%B" {
+		return string(h) + "\n\n", nil
+	}
+	return "", nil
+}
+
+func TestFromHead(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() {
+		require.NoError(t, rootCmd.Flags().Set("from-head", "false"))
+		require.NoError(t, rootCmd.Flags().Set("inline", ""))
+		require.NoError(t, rootCmd.PersistentFlags().Set("amend", "false"))
+	})
+	restore := gitRunner
+	t.Cleanup(func() { gitRunner = restore })
+	gitRunner = func() git.Runner { return headRunner("fix: {{.desc}}\n\nBody") }
+
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"--amend", "--from-head", "-r", "desc=handle nil"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "fix: handle nil\n\nBody\n", out.String())
+
+	require.NoError(t, rootCmd.PersistentFlags().Set("amend", "false"))
+	rootCmd.SetArgs([]string{"--from-head", "-r", "desc=x"})
+	assert.ErrorContains(t, rootCmd.Execute(), "--from-head requires --amend")
+
+	rootCmd.SetArgs([]string{writeTemplate(t, "feat"), "--amend", "--from-head"})
+	assert.ErrorContains(t, rootCmd.Execute(), "cannot be used with a template file")
+
+	rootCmd.SetArgs([]string{"--amend", "--from-head", "--inline", "feat"})
+	assert.ErrorContains(t, rootCmd.Execute(), "--inline cannot be used with --from-head")
+}
@@ -1,3 +1,5 @@
+// Command tcommit generates commit messages from templates.
+// It is a thin wrapper delegating to the cobra root command in cmd/cli.
 package main
 
 import "github.com/WhiCu/TCommit/cmd/cli"
@@ -0,0 +1,83 @@
+package bubble
+
+import (
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// choiceField is a field picking one of a list of choices.
+// Typing narrows the list to the matching choices.
+type choiceField struct {
+	inputField
+	choices []string
+	// highlight is the index of the selected match
+	highlight      int
+	highlightStyle lipgloss.Style
+}
+
+func newChoiceField(n *template.VarNode, value string, st styles) *choiceField {
+	return &choiceField{
+		inputField:     *newInputField(n, value, st),
+		choices:        n.Choices,
+		highlightStyle: st.input,
+	}
+}
+
+// matches returns the choices starting with the typed value, ignoring case
+func (f *choiceField) matches() []string {
+	typed := strings.ToLower(f.Value())
+	var matches []string
+	for _, choice := range f.choices {
+		if strings.HasPrefix(strings.ToLower(choice), typed) {
+			matches = append(matches, choice)
+		}
+	}
+	return matches
+}
+
+// Update handles the keys that pick among the matching choices:
+// up and down move the highlight and enter selects the highlighted match.
+// Other keys edit the filter text.
+func (f *choiceField) Update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if matches := f.matches(); len(matches) > 0 {
+		switch msg.Type {
+		case tea.KeyUp:
+			f.highlight = (f.highlight - 1 + len(matches)) % len(matches)
+			return nil, false
+		case tea.KeyDown:
+			f.highlight = (f.highlight + 1) % len(matches)
+			return nil, false
+		case tea.KeyEnter:
+			f.SetValue(matches[min(f.highlight, len(matches)-1)])
+			f.Blur()
+			f.highlight = 0
+			return nil, true
+		}
+	}
+
+	cmd, _ := f.inputField.Update(msg)
+	f.highlight = 0
+	return cmd, false
+}
+
+// Label lists the matching choices while editing, highlighting the selected one
+func (f *choiceField) Label(editing bool) string {
+	if !editing {
+		return f.inputField.Label(editing)
+	}
+	matches := f.matches()
+	if len(matches) == 0 {
+		return "no matching choice"
+	}
+	parts := make([]string, len(matches))
+	for i, match := range matches {
+		if i == f.highlight {
+			match = f.highlightStyle.Render(match)
+		}
+		parts[i] = match
+	}
+	return strings.Join(parts, " | ")
+}
@@ -2,12 +2,14 @@ package bubble
 
 import (
 	"strings"
+	"time"
 
+	"github.com/WhiCu/TCommit/internal/core/git"
 	"github.com/WhiCu/TCommit/internal/core/template"
-	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -20,10 +22,15 @@ type keyMap struct {
 	Help        key.Binding
 	Quit        key.Binding
 	ChangeState key.Binding
+	Copy        key.Binding
+	ScrollUp    key.Binding
+	ScrollDown  key.Binding
+	Stage       key.Binding
+	NextEmpty   key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit, k.ChangeState}
+	return []key.Binding{k.Help, k.Quit, k.ChangeState, k.Copy}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
@@ -34,6 +41,11 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Back},
 		{k.Enter},
 		{k.ChangeState},
+		{k.Copy},
+		{k.ScrollUp},
+		{k.ScrollDown},
+		{k.Stage},
+		{k.NextEmpty},
 	}
 }
 
@@ -44,6 +56,11 @@ var defaultKeys = keyMap{
 	Back:        key.NewBinding(key.WithKeys("down", "left", "j", "s"), key.WithHelp("↓/🠔/j/s", "move back")),
 	Enter:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
 	ChangeState: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "change state")),
+	Copy:        key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy message")),
+	ScrollUp:    key.NewBinding(key.WithKeys("pgup", "ctrl+u"), key.WithHelp("pgup", "scroll up")),
+	ScrollDown:  key.NewBinding(key.WithKeys("pgdown", "ctrl+d"), key.WithHelp("pgdown", "scroll down")),
+	Stage:       key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "stage/unstage file")),
+	NextEmpty:   key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "next empty field")),
 }
 
 // UI constants
@@ -52,23 +69,9 @@ const (
 	indentHeight  = 3
 	paddingWidth  = 3
 	paddingHeight = 1
-)
 
-// UI styles
-var (
-	inputStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
-
-	titleStyle = func() lipgloss.Style {
-		b := lipgloss.RoundedBorder()
-		b.Right = "├"
-		return lipgloss.NewStyle().BorderStyle(b).Padding(0, 1).Margin(1, 0)
-	}()
-
-	infoStyle = func() lipgloss.Style {
-		b := lipgloss.RoundedBorder()
-		b.Left = "┤"
-		return lipgloss.NewStyle().BorderStyle(b).Padding(0, 1).Margin(1, 0)
-	}()
+	// statusTimeout is how long a transient footer status stays visible
+	statusTimeout = 2 * time.Second
 )
 
 // model represents the application state
@@ -84,70 +87,132 @@ type model struct {
 
 	// UI styles
 	windowStyle lipgloss.Style
+	styles      styles
+
+	// Scrollable template text
+	viewport viewport.Model
 
 	// State
 	staticTexts       []string
 	isInputFocused    bool
-	inputFields       []textinput.Model
+	fields            []focusable
 	currentInputIndex int
+	// submitted is set when the editor is left with enter rather than quit
+	submitted bool
 
 	// Controls
 	keys keyMap
 
 	// Help
 	help help.Model
+
+	// Clipboard
+	writeClipboard func(string) error
+	status         string
+
+	// Staging
+	runner    git.Runner
+	state     state
+	files     []stageFile
+	fileIndex int
 }
 
-// initModel creates a new model with default values
-func initModel(fileName string, tmpl *template.Template, replace map[string]string) tea.Model {
-	h := help.New()
+// clearStatusMsg is sent when the transient footer status should disappear
+type clearStatusMsg struct{}
+
+// flatten lays out the nodes of if blocks inline: the condition followed by both
+// branches, so that every variable gets a field whichever branch is rendered
+func flatten(nodes []template.Node) []template.Node {
+	flat := make([]template.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if n, ok := node.(*template.IfNode); ok {
+			flat = append(flat, n.Cond)
+			flat = append(flat, flatten(n.Then)...)
+			flat = append(flat, flatten(n.Else)...)
+			continue
+		}
+		flat = append(flat, node)
+	}
+	return flat
+}
 
-	windowStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Padding(paddingHeight, paddingWidth)
+// initModel creates a new model with default values.
+// Fields whose variable is already in replace are prefilled with its value instead.
+// With a runner inside a repository, changed files are offered for staging before editing.
+// Parts missing from colors keep their default color.
+func initModel(fileName string, tmpl *template.Template, replace map[string]string, keys keyMap, colors Colors, runner git.Runner) tea.Model {
+	h := help.New()
+	styles := newStyles(colors)
 
-	inputFields := make([]textinput.Model, 0)
+	fields := make([]focusable, 0)
 	staticTexts := make([]string, 0)
-	var input textinput.Model
 	var text strings.Builder
 
-	for _, node := range tmpl.Nodes {
+	nodes := flatten(tmpl.Nodes)
+	for i, node := range nodes {
 		switch n := node.(type) {
 		case *template.TextNode:
 			text.WriteString(n.Text)
+		case *template.FuncNode:
+			// Functions don't take input, show their result as static text
+			// without advancing counters ahead of the final render
+			if err := n.WriteTo(&text, template.Peek(nil)); err != nil {
+				text.WriteString(err.Error())
+			}
 		case *template.VarNode:
 			staticTexts = append(staticTexts, text.String())
+			var after string
+			if i+1 < len(nodes) {
+				if next, ok := nodes[i+1].(*template.TextNode); ok {
+					after = next.Text
+				} else {
+					after = "-" // Followed by another token on the same line
+				}
+			}
+			paragraph := isParagraph(text.String(), after)
 			text.Reset()
 
-			input = textinput.New()
-			input.Prompt = ""
-			input.Placeholder = n.Key
-			input.Width = len(n.Key)
-			if n.Default != "" {
-				input.Width = len(n.Default) + 1
-				input.SetValue(n.Default)
+			// A computed default is left empty and rendered from the other fields
+			var value string
+			if n.DefaultNodes == nil {
+				value = n.Default
 			}
-			input.TextStyle = inputStyle
-			input.Cursor.SetMode(cursor.CursorStatic)
-
-			inputFields = append(inputFields, input)
+			if v, ok := replace[n.Key]; ok {
+				value = v
+			}
+			fields = append(fields, newField(n, paragraph, value, styles))
 		}
 	}
 
 	staticTexts = append(staticTexts, text.String())
 
-	return model{
+	m := model{
 		fileName:          fileName,
 		tmpl:              tmpl,
 		replace:           replace,
-		windowStyle:       windowStyle,
+		windowStyle:       styles.window,
+		styles:            styles,
 		staticTexts:       staticTexts,
 		isInputFocused:    false,
-		inputFields:       inputFields,
+		fields:            fields,
 		currentInputIndex: 0,
 		help:              h,
-		keys:              defaultKeys,
+		keys:              keys,
+		writeClipboard:    clipboard.WriteAll,
+		viewport:          viewport.New(0, 0),
+		runner:            runner,
+		state:             stateEditing,
+	}
+	if runner != nil && git.IsGitRepository(runner) == nil {
+		if err := m.loadFiles(); err != nil {
+			m.status = "listing files failed: " + err.Error()
+		} else if len(m.files) > 0 {
+			m.state = stateStaging
+		}
 	}
+	m.viewport.SetContent(m.buildText())
+
+	return m
 }
 
 // Init implements tea.Model.
@@ -161,35 +226,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case clearStatusMsg:
+		m.status = ""
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
 		m.windowStyle = m.windowStyle.Width(m.width - indentWidth).Height(m.height - indentHeight)
+		m.resizeViewport()
 	case tea.KeyMsg:
+		if m.state == stateStaging {
+			m, cmd = m.updateStaging(msg)
+			cmds = append(cmds, cmd)
+			break
+		}
+
+		field := m.fields[m.currentInputIndex]
 		if key.Matches(msg, m.keys.ChangeState) {
 			m.isInputFocused = !m.isInputFocused
 			if m.isInputFocused {
-				m.inputFields[m.currentInputIndex].Focus()
+				cmds = append(cmds, field.Focus())
 			} else {
-				m.inputFields[m.currentInputIndex].Blur()
+				field.Blur()
 			}
+			break
 		}
 
 		if m.isInputFocused {
-			m.inputFields[m.currentInputIndex], cmd = m.inputFields[m.currentInputIndex].Update(msg)
+			var done bool
+			cmd, done = field.Update(msg)
 			cmds = append(cmds, cmd)
-
-			// Update width based on current value
-			valLen := len(m.inputFields[m.currentInputIndex].Value())
-			placeholderLen := len(m.inputFields[m.currentInputIndex].Placeholder)
-			// Set width to exactly match text length + cursor
-			if valLen > 0 {
-				m.inputFields[m.currentInputIndex].Width = valLen + 1
-			} else {
-				m.inputFields[m.currentInputIndex].Width = placeholderLen
+			if done {
+				m.isInputFocused = false
 			}
-
 			break
 		}
 
@@ -199,49 +268,116 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
 		case key.Matches(msg, m.keys.Forward):
-			m.inputFields[m.currentInputIndex].Blur()
-			m.currentInputIndex = (m.currentInputIndex + 1) % len(m.inputFields)
-			m.inputFields[m.currentInputIndex].Focus()
+			cmds = append(cmds, m.moveFocus((m.currentInputIndex+1)%len(m.fields)))
 		case key.Matches(msg, m.keys.Back):
-			m.inputFields[m.currentInputIndex].Blur()
-			m.currentInputIndex = (m.currentInputIndex - 1 + len(m.inputFields)) % len(m.inputFields)
-			m.inputFields[m.currentInputIndex].Focus()
+			cmds = append(cmds, m.moveFocus((m.currentInputIndex-1+len(m.fields))%len(m.fields)))
+		case key.Matches(msg, m.keys.NextEmpty):
+			cmds = append(cmds, m.moveFocus(m.nextEmptyIndex()))
+		case key.Matches(msg, m.keys.ScrollUp):
+			m.viewport.HalfPageUp()
+		case key.Matches(msg, m.keys.ScrollDown):
+			m.viewport.HalfPageDown()
+		case key.Matches(msg, m.keys.Copy):
+			message, err := m.tmpl.Execute(template.Peek(template.ReplacerFuncFromMap(m.values())))
+			if err == nil {
+				err = m.writeClipboard(message)
+			}
+			if err != nil {
+				m.status = "copy failed: " + err.Error()
+			} else {
+				m.status = "copied"
+			}
+			cmds = append(cmds, m.clearStatus())
 		case key.Matches(msg, m.keys.Enter):
-			for _, inputField := range m.inputFields {
-				if inputField.Value() != "" {
-					m.replace[inputField.Placeholder] = inputField.Value()
-				}
+			for _, field := range m.fields {
+				delete(m.replace, field.Key())
+			}
+			for k, v := range m.values() {
+				m.replace[k] = v
 			}
+			m.submitted = true
 			return m, tea.Quit
 		}
 	}
 
+	m.viewport.SetContent(m.buildText())
+
 	return m, tea.Batch(cmds...)
 }
 
 // View implements tea.Model.
 func (m model) View() string {
-
-	text := m.buildText()
-
 	return lipgloss.JoinVertical(
 		lipgloss.Center,
 		m.windowStyle.Render(
 			m.headerView(),
-			text,
+			m.viewport.View(),
 			m.footerView(),
 		),
 		m.help.View(m.keys),
 	)
 }
 
+// clearStatus schedules the removal of the transient footer status
+func (m model) clearStatus() tea.Cmd {
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// resizeViewport fits the viewport between the header and the footer
+func (m *model) resizeViewport() {
+	m.viewport.Width = max(0, m.width-indentWidth-paddingWidth*2)
+	m.viewport.Height = max(1, m.height-indentHeight-paddingHeight*2-
+		lipgloss.Height(m.headerView())-lipgloss.Height(m.footerView()))
+	for _, field := range m.fields {
+		if area, ok := field.(*areaField); ok {
+			area.SetWidth(m.viewport.Width)
+		}
+	}
+}
+
+// moveFocus moves the focus from the current field to field i
+func (m *model) moveFocus(i int) tea.Cmd {
+	m.fields[m.currentInputIndex].Blur()
+	m.currentInputIndex = i
+	return m.fields[i].Focus()
+}
+
+// nextEmptyIndex returns the index of the first empty field after the current one,
+// wrapping around, or the current index when every other field is filled
+func (m model) nextEmptyIndex() int {
+	for i := 1; i < len(m.fields); i++ {
+		next := (m.currentInputIndex + i) % len(m.fields)
+		if m.fields[next].Value() == "" {
+			return next
+		}
+	}
+	return m.currentInputIndex
+}
+
+// values collects the non-empty input values keyed by variable name
+func (m model) values() map[string]string {
+	values := make(map[string]string, len(m.fields))
+	for _, field := range m.fields {
+		if field.Value() != "" {
+			values[field.Key()] = field.Value()
+		}
+	}
+	return values
+}
+
 func (m model) buildText() string {
+	if m.state == stateStaging {
+		return m.buildFileList()
+	}
+
 	var b strings.Builder
 
 	for i, text := range m.staticTexts {
 		b.WriteString(text)
-		if i < len(m.inputFields) {
-			b.WriteString(m.inputFields[i].View())
+		if i < len(m.fields) {
+			b.WriteString(m.fields[i].View())
 		}
 	}
 
@@ -249,20 +385,50 @@ func (m model) buildText() string {
 }
 
 func (m model) headerView() string {
-	title := titleStyle.Render(m.fileName)
+	title := m.styles.title.Render(m.fileName)
 	line := strings.Repeat("─", max(0, m.width-lipgloss.Width(title)-indentWidth-paddingWidth*2))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m model) footerView() string {
-	info := infoStyle.Render(m.inputFields[m.currentInputIndex].Placeholder)
+	var label string
+	if m.state == stateStaging {
+		label = "stage files"
+	} else {
+		label = m.fields[m.currentInputIndex].Label(m.isInputFocused)
+	}
+	if m.status != "" {
+		label = m.status
+	}
+	info := m.styles.info.Render(label)
 	line := strings.Repeat("─", max(0, m.width-lipgloss.Width(info)-indentWidth-paddingWidth*2))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
 
-func NewProgram(fileName string, tmpl *template.Template, replace map[string]string) *tea.Program {
+// NewProgram creates the interactive editor program. Values already in replace
+// prefill their fields and replace receives the entered values once submitted,
+// see Submitted. Key bindings missing from bindings and parts missing from colors
+// keep their defaults. A nil runner skips the staging screen. Options are applied
+// after the defaults, e.g. to redirect input and output.
+func NewProgram(fileName string, tmpl *template.Template, replace map[string]string, bindings KeyBindings, colors Colors, runner git.Runner, opts ...tea.ProgramOption) (*tea.Program, error) {
+	keys, err := newKeyMap(bindings)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateColors(colors); err != nil {
+		return nil, err
+	}
+
 	return tea.NewProgram(
-		initModel(fileName, tmpl, replace),
-		tea.WithAltScreen(),
-	)
+		initModel(fileName, tmpl, replace, keys, colors, runner),
+		append([]tea.ProgramOption{tea.WithAltScreen()}, opts...)...,
+	), nil
+}
+
+// Submitted reports whether the editor whose final model is m, as returned by
+// running a program of NewProgram, was left with enter. Otherwise it was quit
+// and its values should not be used.
+func Submitted(m tea.Model) bool {
+	final, ok := m.(model)
+	return ok && final.submitted
 }
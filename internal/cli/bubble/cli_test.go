@@ -0,0 +1,336 @@
+package bubble
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestModel parses src and builds a model around it
+func newTestModel(t *testing.T, src string) model {
+	t.Helper()
+	tmpl, err := template.ParseString(src)
+	require.NoError(t, err)
+	return initModel("test.tmpl", tmpl, map[string]string{}, defaultKeys, nil, nil).(model)
+}
+
+// update feeds msg to m and returns the resulting model
+func update(t *testing.T, m model, msg tea.Msg) model {
+	t.Helper()
+	next, _ := m.Update(msg)
+	return next.(model)
+}
+
+func keyRunes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	m := newTestModel(t, "{{.type:@feat}}: {{.desc}}")
+	m.fields[1].SetValue("add login")
+
+	var copied string
+	m.writeClipboard = func(s string) error {
+		copied = s
+		return nil
+	}
+
+	m = update(t, m, keyRunes("c"))
+	assert.Equal(t, "feat: add login", copied)
+	assert.Equal(t, "copied", m.status)
+	assert.Contains(t, m.footerView(), "copied")
+
+	m = update(t, m, clearStatusMsg{})
+	assert.Empty(t, m.status)
+}
+
+func TestCustomKeyMap(t *testing.T) {
+	keys, err := newKeyMap(KeyBindings{"forward": {"n"}, "quit": {"x"}})
+	require.NoError(t, err)
+
+	m := newTestModel(t, "{{.a}} {{.b}}")
+	m.keys = keys
+
+	m = update(t, m, keyRunes("n"))
+	assert.Equal(t, 1, m.currentInputIndex)
+
+	// The old forward key no longer moves focus
+	m = update(t, m, keyRunes("w"))
+	assert.Equal(t, 1, m.currentInputIndex)
+
+	_, cmd := m.Update(keyRunes("x"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestKeyMapValidation(t *testing.T) {
+	_, err := newKeyMap(KeyBindings{"copy": {"q"}})
+	assert.ErrorContains(t, err, `key "q" is bound to both`)
+
+	_, err = newKeyMap(KeyBindings{"jump": {"z"}})
+	assert.ErrorContains(t, err, "unknown key action")
+
+	_, err = newKeyMap(KeyBindings{"enter": {}})
+	assert.ErrorContains(t, err, "no keys bound")
+}
+
+func TestNextEmptyField(t *testing.T) {
+	m := newTestModel(t, "{{.type:@feat}}({{.scope}}): {{.desc:@wip}} {{.issue}}")
+
+	m = update(t, m, keyRunes("e"))
+	assert.Equal(t, 1, m.currentInputIndex)
+
+	// Filled fields are skipped
+	m = update(t, m, keyRunes("e"))
+	assert.Equal(t, 3, m.currentInputIndex)
+
+	// The search wraps around
+	m = update(t, m, keyRunes("e"))
+	assert.Equal(t, 1, m.currentInputIndex)
+
+	m.fields[1].SetValue("core")
+	m.fields[3].SetValue("#42")
+	m = update(t, m, keyRunes("e"))
+	assert.Equal(t, 1, m.currentInputIndex, "focus stays when nothing is empty")
+}
+
+func TestPrefillFromReplace(t *testing.T) {
+	tmpl, err := template.ParseString("{{.type:@feat}}({{.scope}}): {{.desc}}")
+	require.NoError(t, err)
+	replace := map[string]string{"type": "fix", "scope": "core"}
+	m := initModel("test.tmpl", tmpl, replace, defaultKeys, nil, nil).(model)
+
+	assert.Equal(t, "fix", m.fields[0].Value())
+	assert.Equal(t, "core", m.fields[1].Value())
+	assert.Empty(t, m.fields[2].Value())
+
+	// Clearing a prefilled field drops its value
+	m.fields[1].SetValue("")
+	m.fields[2].SetValue("add login")
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, map[string]string{"type": "fix", "desc": "add login"}, replace)
+}
+
+func TestComputedDefaultNotPrefilled(t *testing.T) {
+	m := newTestModel(t, "{{.type}}: {{.title:@{{.type}} stuff}}")
+	assert.Empty(t, m.fields[1].Value())
+
+	// The default is rendered from the other fields instead
+	m.fields[0].SetValue("fix")
+	message, err := m.tmpl.Execute(template.ReplacerFuncFromMap(m.values()))
+	require.NoError(t, err)
+	assert.Equal(t, "fix: fix stuff", message)
+}
+
+func TestSubmitted(t *testing.T) {
+	m := newTestModel(t, "{{.type:@feat}}: {{.desc}}")
+
+	quit, cmd := m.Update(keyRunes("q"))
+	require.NotNil(t, cmd)
+	assert.False(t, Submitted(quit))
+
+	entered, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.True(t, Submitted(entered))
+}
+
+func TestChoiceFiltering(t *testing.T) {
+	tmpl, err := template.ParseString("{{.type:feat|fix|fixup|docs}}: {{.desc}}")
+	require.NoError(t, err)
+	replace := map[string]string{}
+	m := initModel("test.tmpl", tmpl, replace, defaultKeys, nil, nil).(model)
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m = update(t, m, keyRunes("f"))
+	m = update(t, m, keyRunes("i"))
+	assert.Equal(t, []string{"fix", "fixup"}, m.fields[0].(*choiceField).matches())
+	assert.Contains(t, m.footerView(), "fixup")
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.isInputFocused)
+	assert.Equal(t, "fix", m.fields[0].Value())
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "fix", replace["type"])
+}
+
+func TestChoiceHighlight(t *testing.T) {
+	m := newTestModel(t, "{{.type:feat|fix|fixup|docs}}")
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m = update(t, m, keyRunes("F"))
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "fixup", m.fields[0].Value())
+
+	// Without a match the typed text is kept
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m = update(t, m, keyRunes("x"))
+	assert.Empty(t, m.fields[0].(*choiceField).matches())
+	assert.Contains(t, m.footerView(), "no matching choice")
+}
+
+func TestViewportScroll(t *testing.T) {
+	m := newTestModel(t, strings.Repeat("line\n", 40)+"{{.desc}}")
+	m = update(t, m, tea.WindowSizeMsg{Width: 40, Height: 20})
+	require.Less(t, m.viewport.Height, 40)
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	offset := m.viewport.YOffset
+	assert.Positive(t, offset)
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	assert.Greater(t, m.viewport.YOffset, offset)
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyPgUp})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyPgUp})
+	assert.Zero(t, m.viewport.YOffset)
+}
+
+// fakeRunner answers git commands from scripted outputs and records every call
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeRunner) Run(args ...string) (string, error) {
+	call := strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	return f.outputs[call], nil
+}
+
+func TestStagingScreen(t *testing.T) {
+	f := &fakeRunner{outputs: map[string]string{
+		"diff --cached --name-only":                       "api.go",
+		"ls-files --modified --others --exclude-standard": "main.go\nREADME.md",
+	}}
+	tmpl, err := template.ParseString("{{.type:@feat}}: {{.desc}}")
+	require.NoError(t, err)
+	m := initModel("test.tmpl", tmpl, map[string]string{}, defaultKeys, nil, f).(model)
+
+	require.Equal(t, stateStaging, m.state)
+	require.Len(t, m.files, 3)
+	assert.Equal(t, stageFile{path: "README.md"}, m.files[0])
+	assert.Equal(t, stageFile{path: "api.go", staged: true}, m.files[1])
+	assert.Contains(t, m.buildText(), "[x] api.go")
+
+	// Staging the selected file issues git add
+	m = update(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Contains(t, f.calls, "add -- README.md")
+
+	// Unstaging a staged file restores it from the index
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = update(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Contains(t, f.calls, "restore --staged -- api.go")
+
+	// Enter moves on to editing the message
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateEditing, m.state)
+	assert.Contains(t, m.footerView(), "type")
+}
+
+func TestStagingSkippedWithoutChanges(t *testing.T) {
+	f := &fakeRunner{outputs: map[string]string{}}
+	tmpl, err := template.ParseString("{{.desc}}")
+	require.NoError(t, err)
+
+	m := initModel("test.tmpl", tmpl, map[string]string{}, defaultKeys, nil, f).(model)
+	assert.Equal(t, stateEditing, m.state)
+}
+
+func TestFieldDescription(t *testing.T) {
+	m := newTestModel(t, "{{.type:@feat}}({{.scope#desc=the affected module}}): {{.desc}}")
+	assert.Equal(t, "scope: the affected module", m.fields[1].Label(false))
+
+	assert.NotContains(t, m.footerView(), "the affected module")
+
+	m = update(t, m, keyRunes("e"))
+	require.Equal(t, 1, m.currentInputIndex)
+	assert.Contains(t, m.footerView(), "scope: the affected module")
+}
+
+func TestCustomColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	tmpl, err := template.ParseString("{{.type}}: {{.desc}}")
+	require.NoError(t, err)
+
+	m := initModel("test.tmpl", tmpl, map[string]string{}, defaultKeys, Colors{"Input": "#FF00FF", "border": "8"}, nil).(model)
+	assert.Equal(t, lipgloss.Color("#FF00FF"), m.styles.input.GetForeground())
+	assert.Equal(t, lipgloss.Color("#FF00FF"), m.fields[0].(*inputField).TextStyle.GetForeground())
+	assert.Equal(t, lipgloss.Color("8"), m.windowStyle.GetBorderTopForeground())
+	assert.Equal(t, lipgloss.Color(""), m.styles.title.GetForeground(), "missing parts keep their default")
+
+	// NO_COLOR drops the colors but keeps the layout
+	t.Setenv("NO_COLOR", "1")
+	m = initModel("test.tmpl", tmpl, map[string]string{}, defaultKeys, Colors{"input": "#FF00FF"}, nil).(model)
+	assert.Equal(t, lipgloss.Color(""), m.styles.input.GetForeground())
+	assert.Equal(t, lipgloss.RoundedBorder(), m.windowStyle.GetBorderStyle())
+
+	assert.ErrorContains(t, validateColors(Colors{"cursor": "1"}), `unknown color "cursor"`)
+}
+
+func TestFieldOrder(t *testing.T) {
+	m := newTestModel(t, "{{.type:feat|fix}}({{.scope}}): {{.subject}}\n\n{{.body}}\n\nRefs: {{.issue}}")
+
+	require.Len(t, m.fields, 5)
+	assert.IsType(t, &choiceField{}, m.fields[0])
+	assert.IsType(t, &inputField{}, m.fields[1])
+	assert.IsType(t, &inputField{}, m.fields[2])
+	assert.IsType(t, &areaField{}, m.fields[3], "a variable alone in a paragraph is multi-line")
+	assert.IsType(t, &inputField{}, m.fields[4])
+
+	order := []string{"type", "scope", "subject", "body", "issue"}
+	for i := range order {
+		assert.Equal(t, order[i], m.fields[m.currentInputIndex].Key())
+		m = update(t, m, keyRunes("w"))
+	}
+	assert.Equal(t, "type", m.fields[m.currentInputIndex].Key(), "forward wraps around")
+
+	for i := len(order) - 1; i >= 0; i-- {
+		m = update(t, m, keyRunes("s"))
+		assert.Equal(t, order[i], m.fields[m.currentInputIndex].Key())
+	}
+
+	// Enter starts a new line in the multi-line field instead of submitting
+	m = update(t, m, keyRunes("s"))
+	m = update(t, m, keyRunes("s"))
+	require.Equal(t, "body", m.fields[m.currentInputIndex].Key())
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m = update(t, m, keyRunes("first"))
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = update(t, m, keyRunes("second"))
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	assert.Equal(t, "first\nsecond", m.values()["body"])
+}
+
+func TestIfBlockFields(t *testing.T) {
+	m := newTestModel(t, "{{.type}}{{if .breaking:yes|no|@no}}!{{.note}}{{else}}{{.scope?}}{{end}}: {{.subject}}")
+
+	keys := make([]string, len(m.fields))
+	for i, f := range m.fields {
+		keys[i] = f.Key()
+	}
+	assert.Equal(t, []string{"type", "breaking", "note", "scope", "subject"}, keys)
+	assert.Equal(t, "no", m.fields[1].Value())
+}
+
+func TestDisplayLeavesCounters(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	m := newTestModel(t, `#{{counter "build"}} {{.desc}}`)
+	m.fields[0].SetValue("x")
+	m.writeClipboard = func(string) error { return nil }
+	m = update(t, m, keyRunes("c"))
+	assert.Equal(t, "copied", m.status)
+	assert.Equal(t, "#1 ", m.staticTexts[0])
+
+	assert.NoFileExists(t, filepath.Join(dir, "tcommit", "counters.json"))
+}
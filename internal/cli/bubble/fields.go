@@ -0,0 +1,140 @@
+package bubble
+
+import (
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// areaHeight is the number of lines a multi-line field shows
+const areaHeight = 3
+
+// focusable is a field filling in one template variable.
+// The model keeps its fields in template order and moves the focus along them.
+type focusable interface {
+	// Key is the name of the variable the field fills in
+	Key() string
+	Value() string
+	SetValue(s string)
+	Focus() tea.Cmd
+	Blur()
+	// Update handles a key pressed while editing the field and reports
+	// whether the field is done with it, e.g. once a choice is picked
+	Update(msg tea.KeyMsg) (cmd tea.Cmd, done bool)
+	View() string
+	// Label describes the field in the footer, while editing it or not
+	Label(editing bool) string
+}
+
+// newField creates the field for n prefilled with value: a list of the choices
+// when n restricts its value, a multi-line area when n fills a paragraph of its
+// own, such as the body of the message, and a single line input otherwise
+func newField(n *template.VarNode, paragraph bool, value string, st styles) focusable {
+	switch {
+	case len(n.Choices) > 0:
+		return newChoiceField(n, value, st)
+	case paragraph:
+		return newAreaField(n, value, st)
+	}
+	return newInputField(n, value, st)
+}
+
+// isParagraph reports whether a variable between the texts before and after it
+// stands alone in a paragraph
+func isParagraph(before, after string) bool {
+	return strings.HasSuffix(before, "\n\n") && (after == "" || strings.HasPrefix(after, "\n"))
+}
+
+// inputField is a single line field
+type inputField struct {
+	textinput.Model
+	desc string
+}
+
+func newInputField(n *template.VarNode, value string, st styles) *inputField {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = n.Key
+	input.TextStyle = st.input
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	f := &inputField{Model: input, desc: n.Desc}
+	f.SetValue(value)
+	return f
+}
+
+func (f *inputField) Key() string {
+	return f.Placeholder
+}
+
+func (f *inputField) SetValue(s string) {
+	f.Model.SetValue(s)
+	f.fit()
+}
+
+func (f *inputField) Update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	var cmd tea.Cmd
+	f.Model, cmd = f.Model.Update(msg)
+	f.fit()
+	return cmd, false
+}
+
+func (f *inputField) Label(bool) string {
+	return describe(f.Key(), f.desc)
+}
+
+// fit sizes the field to its value plus the cursor, or to its placeholder when empty
+func (f *inputField) fit() {
+	if valLen := len(f.Model.Value()); valLen > 0 {
+		f.Width = valLen + 1
+	} else {
+		f.Width = len(f.Placeholder)
+	}
+}
+
+// areaField is a multi-line field; enter starts a new line while editing it
+type areaField struct {
+	textarea.Model
+	key  string
+	desc string
+}
+
+func newAreaField(n *template.VarNode, value string, st styles) *areaField {
+	area := textarea.New()
+	area.Prompt = ""
+	area.Placeholder = n.Key
+	area.ShowLineNumbers = false
+	area.CharLimit = 0
+	area.SetHeight(areaHeight)
+	area.FocusedStyle.Text = st.input
+	area.BlurredStyle.Text = st.input
+	area.FocusedStyle.CursorLine = st.input
+	area.SetValue(value)
+	return &areaField{Model: area, key: n.Key, desc: n.Desc}
+}
+
+func (f *areaField) Key() string {
+	return f.key
+}
+
+func (f *areaField) Update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	var cmd tea.Cmd
+	f.Model, cmd = f.Model.Update(msg)
+	return cmd, false
+}
+
+func (f *areaField) Label(bool) string {
+	return describe(f.key, f.desc)
+}
+
+// describe labels a field by its key and description
+func describe(key, desc string) string {
+	if desc == "" {
+		return key
+	}
+	return key + ": " + desc
+}
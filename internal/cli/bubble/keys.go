@@ -0,0 +1,70 @@
+package bubble
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyBindings maps action names (forward, back, enter, help, quit,
+// change_state, copy, scroll_up, scroll_down, stage, next_empty) to the keys that trigger them.
+type KeyBindings map[string][]string
+
+// actions returns the bindings of k addressable by action name
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"forward":      &k.Forward,
+		"back":         &k.Back,
+		"enter":        &k.Enter,
+		"help":         &k.Help,
+		"quit":         &k.Quit,
+		"change_state": &k.ChangeState,
+		"copy":         &k.Copy,
+		"scroll_up":    &k.ScrollUp,
+		"scroll_down":  &k.ScrollDown,
+		"stage":        &k.Stage,
+		"next_empty":   &k.NextEmpty,
+	}
+}
+
+// newKeyMap builds a keyMap from the defaults with the given overrides applied.
+// It returns an error for unknown actions, empty bindings or keys bound to
+// more than one action.
+func newKeyMap(overrides KeyBindings) (keyMap, error) {
+	keys := defaultKeys
+	actions := keys.actions()
+
+	for action, bound := range overrides {
+		binding, ok := actions[strings.ToLower(action)]
+		if !ok {
+			return keyMap{}, fmt.Errorf("unknown key action %q", action)
+		}
+		if len(bound) == 0 {
+			return keyMap{}, fmt.Errorf("no keys bound to action %q", action)
+		}
+		*binding = key.NewBinding(
+			key.WithKeys(bound...),
+			key.WithHelp(strings.Join(bound, "/"), binding.Help().Desc),
+		)
+	}
+
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string)
+	for _, name := range names {
+		for _, k := range actions[name].Keys() {
+			if other, ok := seen[k]; ok {
+				return keyMap{}, fmt.Errorf("key %q is bound to both %q and %q", k, other, name)
+			}
+			seen[k] = name
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,120 @@
+package bubble
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/WhiCu/TCommit/internal/core/git"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// state is the screen the editor is showing
+type state int
+
+const (
+	// stateStaging lists changed files so they can be staged before editing
+	stateStaging state = iota
+	// stateEditing fills in the template variables
+	stateEditing
+)
+
+// stageFile is a changed file shown on the staging screen
+type stageFile struct {
+	path   string
+	staged bool
+}
+
+// loadFiles refreshes the changed files from git.
+// Files with changes both in the index and the work tree are shown as unstaged
+// so that toggling them stages the rest.
+func (m *model) loadFiles() error {
+	staged, err := git.GetStagedFiles(m.runner)
+	if err != nil {
+		return err
+	}
+	unstaged, err := git.GetUnstagedFiles(m.runner)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]bool, len(staged)+len(unstaged))
+	for _, path := range staged {
+		files[path] = true
+	}
+	for _, path := range unstaged {
+		files[path] = false
+	}
+
+	m.files = m.files[:0]
+	for path, isStaged := range files {
+		m.files = append(m.files, stageFile{path: path, staged: isStaged})
+	}
+	sort.Slice(m.files, func(i, j int) bool {
+		return m.files[i].path < m.files[j].path
+	})
+	m.fileIndex = min(m.fileIndex, max(0, len(m.files)-1))
+
+	return nil
+}
+
+// toggleStaged stages the selected file or unstages it if already staged
+func (m *model) toggleStaged() error {
+	file := m.files[m.fileIndex]
+	var err error
+	if file.staged {
+		err = git.UnstageFiles(m.runner, file.path)
+	} else {
+		err = git.StageFiles(m.runner, file.path)
+	}
+	if err != nil {
+		return err
+	}
+	return m.loadFiles()
+}
+
+// updateStaging handles a key press on the staging screen
+func (m model) updateStaging(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Forward):
+		m.fileIndex = (m.fileIndex - 1 + len(m.files)) % len(m.files)
+	case key.Matches(msg, m.keys.Back):
+		m.fileIndex = (m.fileIndex + 1) % len(m.files)
+	case key.Matches(msg, m.keys.Stage):
+		if err := m.toggleStaged(); err != nil {
+			m.status = "staging failed: " + err.Error()
+			return m, m.clearStatus()
+		}
+	case key.Matches(msg, m.keys.Enter):
+		m.state = stateEditing
+	}
+	return m, nil
+}
+
+// buildFileList renders the staging screen
+func (m model) buildFileList() string {
+	var b strings.Builder
+	b.WriteString("Changed files:\n\n")
+	for i, file := range m.files {
+		cursor := "  "
+		if i == m.fileIndex {
+			cursor = "> "
+		}
+		mark := "[ ] "
+		if file.staged {
+			mark = "[x] "
+		}
+		b.WriteString(cursor + mark)
+		if i == m.fileIndex {
+			b.WriteString(m.styles.input.Render(file.path))
+		} else {
+			b.WriteString(file.path)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
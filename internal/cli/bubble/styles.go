@@ -0,0 +1,77 @@
+package bubble
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Colors maps the styled parts of the editor (input, border, title) to colors,
+// given as hex codes such as "#00FF00" or ANSI color numbers such as "10".
+type Colors map[string]string
+
+// defaultColors are used for the parts missing from the configured colors.
+// An empty color keeps the terminal default.
+var defaultColors = Colors{
+	"input":  "#00FF00",
+	"border": "",
+	"title":  "",
+}
+
+// styles are the lipgloss styles the editor renders with
+type styles struct {
+	input  lipgloss.Style
+	title  lipgloss.Style
+	info   lipgloss.Style
+	window lipgloss.Style
+}
+
+// validateColors returns an error for parts of colors the editor does not style
+func validateColors(colors Colors) error {
+	for part := range colors {
+		if _, ok := defaultColors[strings.ToLower(part)]; !ok {
+			return fmt.Errorf("unknown color %q", part)
+		}
+	}
+	return nil
+}
+
+// noColor reports whether styling is disabled through the NO_COLOR convention
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// newStyles builds the editor styles from the defaults with colors applied.
+// With NO_COLOR set only the layout is kept.
+func newStyles(colors Colors) styles {
+	resolved := make(Colors, len(defaultColors))
+	for part, color := range defaultColors {
+		resolved[part] = color
+	}
+	for part, color := range colors {
+		resolved[strings.ToLower(part)] = color
+	}
+	if noColor() {
+		clear(resolved)
+	}
+
+	border := lipgloss.Color(resolved["border"])
+
+	titleBorder := lipgloss.RoundedBorder()
+	titleBorder.Right = "├"
+	infoBorder := lipgloss.RoundedBorder()
+	infoBorder.Left = "┤"
+
+	return styles{
+		input: lipgloss.NewStyle().Foreground(lipgloss.Color(resolved["input"])),
+		title: lipgloss.NewStyle().BorderStyle(titleBorder).BorderForeground(border).
+			Foreground(lipgloss.Color(resolved["title"])).Padding(0, 1).Margin(1, 0),
+		info: lipgloss.NewStyle().BorderStyle(infoBorder).BorderForeground(border).Padding(0, 1).Margin(1, 0),
+		window: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Padding(paddingHeight, paddingWidth),
+	}
+}
@@ -0,0 +1,34 @@
+package commit
+
+import "strings"
+
+// commentPrefix starts the lines git strips from an edited commit message
+const commentPrefix = "#"
+
+// scissorsLine marks where git commit --verbose appends the diff.
+// Everything below it is dropped by git, whatever it looks like.
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// MergeEditMsg returns the content of a commit message file such as
+// .git/COMMIT_EDITMSG holding message instead of the message of existing.
+// The comment lines of existing, and anything from the verbose scissors line on,
+// are kept below message after a blank line, as git lays them out.
+func MergeEditMsg(existing, message string) string {
+	var kept []string
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	for i, line := range lines {
+		if line == scissorsLine {
+			kept = append(kept, lines[i:]...)
+			break
+		}
+		if strings.HasPrefix(line, commentPrefix) {
+			kept = append(kept, line)
+		}
+	}
+
+	message = strings.TrimRight(message, "\n") + "\n"
+	if len(kept) == 0 {
+		return message
+	}
+	return message + "\n" + strings.Join(kept, "\n") + "\n"
+}
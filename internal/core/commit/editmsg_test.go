@@ -0,0 +1,44 @@
+package commit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeEditMsg(t *testing.T) {
+	comments := "# Please enter the commit message for your changes.\n#\n# On branch main\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		message  string
+		want     string
+	}{
+		{name: "No file", existing: "", message: "feat: add login", want: "feat: add login\n"},
+		{
+			name:     "Comments kept below",
+			existing: "\n" + comments,
+			message:  "feat: add login\n\nBody\n",
+			want:     "feat: add login\n\nBody\n\n" + comments,
+		},
+		{
+			name:     "Previous message replaced",
+			existing: "wip\n\nold body\n" + comments,
+			message:  "feat: add login",
+			want:     "feat: add login\n\n" + comments,
+		},
+		{
+			name:     "Verbose diff kept",
+			existing: "\n# On branch main\n" + scissorsLine + "\n# Do not modify or remove the line above.\ndiff --git a/x b/x\n+added\n",
+			message:  "fix: typo",
+			want:     "fix: typo\n\n# On branch main\n" + scissorsLine + "\n# Do not modify or remove the line above.\ndiff --git a/x b/x\n+added\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergeEditMsg(tt.existing, tt.message))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package commit
+
+import "strings"
+
+// Normalize collapses every run of blank lines of message into a single one,
+// drops the leading and trailing ones and makes it end with exactly one newline.
+// Lines holding only whitespace count as blank. A blank message becomes empty.
+func Normalize(message string) string {
+	lines := strings.Split(message, "\n")
+
+	var b strings.Builder
+	b.Grow(len(message) + 1)
+	blank := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		blank = false
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
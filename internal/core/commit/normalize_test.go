@@ -0,0 +1,30 @@
+package commit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "No trailing newline", message: "feat: add login", want: "feat: add login\n"},
+		{name: "One trailing newline", message: "feat: add login\n", want: "feat: add login\n"},
+		{name: "Multiple trailing newlines", message: "feat: add login\n\n\n \n", want: "feat: add login\n"},
+		{name: "Body kept", message: "feat: add login\n\nBody\nmore", want: "feat: add login\n\nBody\nmore\n"},
+		{name: "Excessive blank lines", message: "feat: add login\n\n\n\nBody\n\t\n\n\nFooter", want: "feat: add login\n\nBody\n\nFooter\n"},
+		{name: "Leading blank lines", message: "\n\nfeat: add login", want: "feat: add login\n"},
+		{name: "Empty", message: "", want: ""},
+		{name: "Only whitespace", message: " \n\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.message))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package commit provides helpers for shaping commit messages before they
+// are handed to git.
+package commit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CoAuthorKey is the git trailer key crediting additional authors
+const CoAuthorKey = "Co-authored-by"
+
+// trailerPattern matches a single "Key: value" trailer line
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+
+// Trailer formats a "Key: value" trailer line
+func Trailer(key, value string) string {
+	return key + ": " + value
+}
+
+// AppendTrailers appends trailer lines to message following git conventions.
+// Trailers are separated from the message by a blank line unless the message
+// already ends with a trailer block, in which case they extend it.
+// Trailers already present in that block are not repeated.
+func AppendTrailers(message string, trailers []string) string {
+	message = strings.TrimRight(message, " \t\r\n")
+	if len(trailers) == 0 {
+		return message
+	}
+
+	existing := lastTrailerBlock(message)
+	present := make(map[string]bool, len(existing))
+	for _, line := range existing {
+		present[line] = true
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	switch {
+	case message == "":
+	case len(existing) > 0:
+		b.WriteString("\n")
+	default:
+		b.WriteString("\n\n")
+	}
+
+	first := true
+	for _, trailer := range trailers {
+		if present[trailer] {
+			continue
+		}
+		present[trailer] = true
+		if !first {
+			b.WriteString("\n")
+		}
+		b.WriteString(trailer)
+		first = false
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// lastTrailerBlock returns the lines of the final paragraph of message
+// if every one of them is a trailer, and nil otherwise. The subject line
+// never counts as a trailer block.
+func lastTrailerBlock(message string) []string {
+	idx := strings.LastIndex(message, "\n\n")
+	if idx < 0 {
+		return nil
+	}
+
+	lines := strings.Split(message[idx+2:], "\n")
+	for _, line := range lines {
+		if !trailerPattern.MatchString(line) {
+			return nil
+		}
+	}
+	return lines
+}
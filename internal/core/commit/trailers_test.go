@@ -0,0 +1,71 @@
+package commit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendTrailers(t *testing.T) {
+	alice := Trailer(CoAuthorKey, "Alice <alice@example.com>")
+	bob := Trailer(CoAuthorKey, "Bob <bob@example.com>")
+
+	tests := []struct {
+		name     string
+		message  string
+		trailers []string
+		want     string
+	}{
+		{
+			name:     "Single co-author",
+			message:  "feat: add login\n",
+			trailers: []string{alice},
+			want:     "feat: add login\n\nCo-authored-by: Alice <alice@example.com>",
+		},
+		{
+			name:     "Multiple co-authors after body",
+			message:  "feat: add login\n\nAdds the login form.",
+			trailers: []string{alice, bob},
+			want: "feat: add login\n\nAdds the login form.\n\n" +
+				"Co-authored-by: Alice <alice@example.com>\n" +
+				"Co-authored-by: Bob <bob@example.com>",
+		},
+		{
+			name:     "Existing trailer block is extended",
+			message:  "fix: typo\n\nSigned-off-by: Carol <carol@example.com>\n\n",
+			trailers: []string{alice},
+			want: "fix: typo\n\nSigned-off-by: Carol <carol@example.com>\n" +
+				"Co-authored-by: Alice <alice@example.com>",
+		},
+		{
+			name:     "Present trailers are not repeated",
+			message:  "fix: typo\n\n" + alice,
+			trailers: []string{alice, bob, bob},
+			want:     "fix: typo\n\n" + alice + "\n" + bob,
+		},
+		{
+			name:     "Subject that looks like a trailer",
+			message:  "fix: typo",
+			trailers: []string{bob},
+			want:     "fix: typo\n\n" + bob,
+		},
+		{
+			name:     "No trailers",
+			message:  "fix: typo\n",
+			trailers: nil,
+			want:     "fix: typo",
+		},
+		{
+			name:     "Empty message",
+			message:  "",
+			trailers: []string{alice},
+			want:     alice,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, AppendTrailers(tc.message, tc.trailers))
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind identifies why a commit message failed validation
+type Kind int
+
+const (
+	// KindEmptySubject means the message has no subject line
+	KindEmptySubject Kind = iota + 1
+	// KindSubjectTooLong means the subject line exceeds the length limit
+	KindSubjectTooLong
+	// KindBadFormat means the subject does not follow the required format
+	KindBadFormat
+	// KindMissingBlankLine means the body does not start after a blank line
+	KindMissingBlankLine
+	// KindEmptyMessage means the message is empty or only whitespace
+	KindEmptyMessage
+	// KindStrayMarker means the message still holds a template marker
+	KindStrayMarker
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindEmptySubject:
+		return "empty subject"
+	case KindSubjectTooLong:
+		return "subject too long"
+	case KindBadFormat:
+		return "bad format"
+	case KindMissingBlankLine:
+		return "missing blank line"
+	case KindEmptyMessage:
+		return "empty message"
+	case KindStrayMarker:
+		return "stray template marker"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// ValidationError is returned by the message validators.
+// errors.Is matches it against the sentinel of its Kind, e.g. ErrSubjectTooLong.
+type ValidationError struct {
+	Kind   Kind
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Detail == "" {
+		return e.Kind.String()
+	}
+	return e.Kind.String() + ": " + e.Detail
+}
+
+// Is reports whether target is a ValidationError of the same kind
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && t.Kind == e.Kind
+}
+
+// Sentinels for errors.Is
+var (
+	ErrEmptySubject     = &ValidationError{Kind: KindEmptySubject}
+	ErrSubjectTooLong   = &ValidationError{Kind: KindSubjectTooLong}
+	ErrBadFormat        = &ValidationError{Kind: KindBadFormat}
+	ErrMissingBlankLine = &ValidationError{Kind: KindMissingBlankLine}
+	ErrEmptyMessage     = &ValidationError{Kind: KindEmptyMessage}
+	ErrStrayMarker      = &ValidationError{Kind: KindStrayMarker}
+)
+
+// conventionalPattern matches a Conventional Commits subject: type(scope)!: description
+var conventionalPattern = regexp.MustCompile(`^[a-z]+(\([^()\s]+\))?!?: \S`)
+
+// subject returns the first line of message
+func subject(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+// ValidateNotEmpty checks that message has more than whitespace
+func ValidateNotEmpty(message string) error {
+	if strings.TrimSpace(message) == "" {
+		return &ValidationError{Kind: KindEmptyMessage}
+	}
+	return nil
+}
+
+// ValidateSubject checks that message has a subject of at most maxLen runes.
+// A maxLen of 0 only requires a subject.
+func ValidateSubject(message string, maxLen int) error {
+	s := subject(message)
+	if strings.TrimSpace(s) == "" {
+		return &ValidationError{Kind: KindEmptySubject}
+	}
+	if n := utf8.RuneCountInString(s); maxLen > 0 && n > maxLen {
+		return &ValidationError{
+			Kind:   KindSubjectTooLong,
+			Detail: fmt.Sprintf("%d characters, at most %d allowed", n, maxLen),
+		}
+	}
+	return nil
+}
+
+// ValidateConventional checks that the subject follows Conventional Commits
+func ValidateConventional(message string) error {
+	if s := subject(message); !conventionalPattern.MatchString(s) {
+		return &ValidationError{
+			Kind:   KindBadFormat,
+			Detail: fmt.Sprintf("%q is not \"type(scope): description\"", s),
+		}
+	}
+	return nil
+}
+
+// ValidateBlankLine checks that a body is separated from the subject by a blank line
+func ValidateBlankLine(message string) error {
+	lines := strings.Split(strings.TrimRight(message, "\r\n"), "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		return &ValidationError{Kind: KindMissingBlankLine}
+	}
+	return nil
+}
+
+// templateMarkers are the template delimiters that should not survive rendering
+var templateMarkers = []string{"{{", "}}"}
+
+// markerContext is how many bytes around a stray marker are quoted
+const markerContext = 12
+
+// ValidateNoMarkers checks that no template marker was left in message,
+// as happens when a stray {{ in a template is taken as text.
+// The error points at the first marker found.
+func ValidateNoMarkers(message string) error {
+	pos := -1
+	for _, marker := range templateMarkers {
+		if i := strings.Index(message, marker); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	if pos < 0 {
+		return nil
+	}
+
+	lineStart := strings.LastIndexByte(message[:pos], '\n') + 1
+	lineEnd := len(message)
+	if i := strings.IndexByte(message[pos:], '\n'); i >= 0 {
+		lineEnd = pos + i
+	}
+	snippet := message[max(lineStart, pos-markerContext):min(lineEnd, pos+2+markerContext)]
+
+	return &ValidationError{
+		Kind: KindStrayMarker,
+		Detail: fmt.Sprintf("%q at line %d, column %d: %q",
+			message[pos:pos+2], strings.Count(message[:pos], "\n")+1, utf8.RuneCountInString(message[lineStart:pos])+1, snippet),
+	}
+}
+
+// Rules selects the validators Validate runs; the zero value accepts any message
+type Rules struct {
+	// NonEmpty rejects messages that are empty or only whitespace
+	NonEmpty bool
+	// MaxSubjectLength requires a subject of at most this many runes; 0 disables the check
+	MaxSubjectLength int
+	// Conventional requires a Conventional Commits subject
+	Conventional bool
+	// RequireBlankLine requires a blank line between the subject and the body
+	RequireBlankLine bool
+	// NoMarkers rejects messages still holding template markers
+	NoMarkers bool
+}
+
+// Validate runs the validators enabled by rules and returns every failure
+func (r Rules) Validate(message string) []error {
+	if r.NonEmpty {
+		if err := ValidateNotEmpty(message); err != nil {
+			// Nothing else is worth reporting about an empty message
+			return []error{err}
+		}
+	}
+
+	var errs []error
+	if r.MaxSubjectLength > 0 {
+		if err := ValidateSubject(message, r.MaxSubjectLength); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.Conventional {
+		if err := ValidateConventional(message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.RequireBlankLine {
+		if err := ValidateBlankLine(message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.NoMarkers {
+		if err := ValidateNoMarkers(message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,85 @@
+package commit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{name: "Empty subject", err: ValidateSubject("\n\nbody", 0), want: KindEmptySubject},
+		{name: "Subject too long", err: ValidateSubject(strings.Repeat("x", 73), 72), want: KindSubjectTooLong},
+		{name: "Bad format", err: ValidateConventional("Added login"), want: KindBadFormat},
+		{name: "Missing blank line", err: ValidateBlankLine("feat: add login\nbody"), want: KindMissingBlankLine},
+		{name: "Empty message", err: ValidateNotEmpty(" \n\t\n"), want: KindEmptyMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			require.True(t, errors.As(tt.err, &verr))
+			assert.Equal(t, tt.want, verr.Kind)
+
+			// Wrapped errors still match the sentinel of their kind
+			wrapped := fmt.Errorf("invalid message: %w", tt.err)
+			assert.ErrorIs(t, wrapped, &ValidationError{Kind: tt.want})
+			assert.NotErrorIs(t, wrapped, &ValidationError{Kind: tt.want + 1})
+		})
+	}
+}
+
+func TestValidatorsAccept(t *testing.T) {
+	message := "feat(auth)!: add login\n\nLonger body\nover lines\n"
+
+	assert.NoError(t, ValidateSubject(message, 72))
+	assert.NoError(t, ValidateSubject(strings.Repeat("é", 72), 72))
+	assert.NoError(t, ValidateConventional(message))
+	assert.NoError(t, ValidateConventional("fix: typo"))
+	assert.NoError(t, ValidateBlankLine(message))
+	assert.NoError(t, ValidateBlankLine("fix: typo\n"))
+	assert.NoError(t, ValidateNotEmpty(message))
+}
+
+func TestRulesValidate(t *testing.T) {
+	rules := Rules{MaxSubjectLength: 10, Conventional: true, RequireBlankLine: true}
+
+	errs := rules.Validate("Added the login page\nbody")
+	require.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], ErrSubjectTooLong)
+	assert.ErrorIs(t, errs[1], ErrBadFormat)
+	assert.ErrorIs(t, errs[2], ErrMissingBlankLine)
+
+	assert.Empty(t, rules.Validate("fix: typo"))
+	assert.Empty(t, Rules{}.Validate("Anything goes\nhere"))
+
+	// An empty message is only reported as such
+	errs = Rules{NonEmpty: true, MaxSubjectLength: 10, Conventional: true}.Validate("  \n")
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrEmptyMessage)
+	assert.Empty(t, Rules{NonEmpty: true}.Validate("fix: typo"))
+}
+
+func TestValidateNoMarkers(t *testing.T) {
+	assert.NoError(t, ValidateNoMarkers("feat: add login\n\nUses a { brace } pair"))
+
+	err := ValidateNoMarkers("feat: add login\n\nFixes {{.ticket and more text after it")
+	require.ErrorIs(t, err, ErrStrayMarker)
+	assert.EqualError(t, err, `stray template marker: "{{" at line 3, column 7: "Fixes {{.ticket and "`)
+
+	err = ValidateNoMarkers("fix: close }} early")
+	require.ErrorIs(t, err, ErrStrayMarker)
+	assert.Contains(t, err.Error(), `"}}" at line 1, column 12`)
+
+	errs := Rules{NoMarkers: true}.Validate("fix: {{ typo")
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrStrayMarker)
+}
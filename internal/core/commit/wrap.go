@@ -0,0 +1,65 @@
+package commit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// codeFence opens and closes a fenced code block
+const codeFence = "```"
+
+// WrapBody breaks the body lines of message longer than width columns at spaces.
+// The subject line is left as is, and so are trailers, indented lines and fenced
+// code blocks, whose layout matters. Lines are only split, never joined, and a
+// word longer than width keeps a line of its own. A width below 1 disables wrapping.
+func WrapBody(message string, width int) string {
+	subject, body, ok := strings.Cut(message, "\n")
+	if width < 1 || !ok {
+		return message
+	}
+
+	var b strings.Builder
+	b.Grow(len(message) + len(message)/width)
+	b.WriteString(subject)
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		b.WriteString("\n")
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			inFence = !inFence
+		}
+		if inFence || utf8.RuneCountInString(line) <= width || !wrappable(line) {
+			b.WriteString(line)
+			continue
+		}
+		wrapLine(&b, line, width)
+	}
+	return b.String()
+}
+
+// wrappable reports whether a body line is prose that may be wrapped
+func wrappable(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	return !trailerPattern.MatchString(line)
+}
+
+// wrapLine writes line to b broken at spaces into lines of at most width runes
+func wrapLine(b *strings.Builder, line string, width int) {
+	col := 0
+	for i, word := range strings.Fields(line) {
+		n := utf8.RuneCountInString(word)
+		switch {
+		case i == 0:
+		case col+1+n > width:
+			b.WriteString("\n")
+			col = 0
+		default:
+			b.WriteString(" ")
+			col++
+		}
+		b.WriteString(word)
+		col += n
+	}
+}
@@ -0,0 +1,43 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapBody(t *testing.T) {
+	subject := "feat: add a login form that is longer than the wrapping width of twenty"
+	message := subject + "\n\n" +
+		"The form validates the email address before submitting it to the server.\n" +
+		"Short line.\n" +
+		"\n" +
+		"Reviewed-by: Jane Doe <jane.doe@example.com> who looked at it thoroughly"
+
+	want := subject + "\n\n" +
+		"The form validates\n" +
+		"the email address\n" +
+		"before submitting it\n" +
+		"to the server.\n" +
+		"Short line.\n" +
+		"\n" +
+		"Reviewed-by: Jane Doe <jane.doe@example.com> who looked at it thoroughly"
+	assert.Equal(t, want, WrapBody(message, 20))
+
+	for _, line := range strings.Split(WrapBody(message, 20), "\n")[1:6] {
+		assert.LessOrEqual(t, len(line), 20, line)
+	}
+}
+
+func TestWrapBodyKeepsLayout(t *testing.T) {
+	code := "feat: x\n\n```\nthis code line is much too long to fit in twenty columns\n```\n" +
+		"    indented line that is also far too long to fit in twenty"
+	assert.Equal(t, code, WrapBody(code, 20))
+
+	long := "feat: x\n\nan-unbreakable-word-longer-than-the-width end"
+	assert.Equal(t, "feat: x\n\nan-unbreakable-word-longer-than-the-width\nend", WrapBody(long, 20))
+
+	assert.Equal(t, "feat: only a subject that is rather long", WrapBody("feat: only a subject that is rather long", 10))
+	assert.Equal(t, long, WrapBody(long, 0))
+}
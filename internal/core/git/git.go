@@ -1,9 +1,14 @@
 package git
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -11,57 +16,277 @@ import (
 type GitError struct {
 	Command string
 	Err     error
+	// Stdout and Stderr hold what git printed, e.g. the message of a rejecting hook.
+	// Commands attached to the process stdio only keep Stderr.
+	Stdout string
+	Stderr string
+	// ExitCode is the exit status of git, or -1 when it did not run to completion
+	ExitCode int
 }
 
 func (e *GitError) Error() string {
 	return fmt.Sprintf("git %s: %v", e.Command, e.Err)
 }
 
-// runGitCommand executes a git command and returns its output
-func runGitCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidState is matched by errors.Is for failed git state checks,
+// such as a commit without staged changes
+var ErrInvalidState = errors.New("invalid git state")
+
+// stateError is a failed git state check
+type stateError string
+
+func (e stateError) Error() string {
+	return string(e)
+}
+
+func (e stateError) Is(target error) bool {
+	return target == ErrInvalidState
+}
+
+// Runner executes git commands.
+// All git helpers go through a Runner so they can be exercised without a real repository.
+type Runner interface {
+	// Run executes git with args and returns its trimmed output
+	Run(args ...string) (string, error)
+}
+
+// AttachedRunner is optionally implemented by runners that can connect git
+// to the process stdio. Commit and push use it when available.
+type AttachedRunner interface {
+	Runner
+	RunAttached(args ...string) error
+}
+
+// runAttached executes a git command attached to the stdio when r supports it
+func runAttached(r Runner, args ...string) error {
+	if a, ok := r.(AttachedRunner); ok {
+		return a.RunAttached(args...)
+	}
+	_, err := r.Run(args...)
+	return err
+}
+
+// DefaultBinary is the git executable used when none is configured
+const DefaultBinary = "git"
+
+// ExecRunner runs the git binary in a working directory
+type ExecRunner struct {
+	// Dir is the directory git runs in; empty means the process working directory
+	Dir string
+	// Bin is the git executable; empty means DefaultBinary looked up in PATH
+	Bin string
+}
+
+// command builds a git invocation running in r.Dir
+func (r ExecRunner) command(args ...string) *exec.Cmd {
+	bin := r.Bin
+	if bin == "" {
+		bin = DefaultBinary
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = r.Dir
+	return cmd
+}
+
+// Run executes a git command and returns its combined output.
+// On failure the output streams are kept apart in the returned *GitError.
+func (r ExecRunner) Run(args ...string) (string, error) {
+	cmd := r.command(args...)
+	var stdout, stderr, output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, &output)
+	cmd.Stderr = io.MultiWriter(&stderr, &output)
+	if err := cmd.Run(); err != nil {
 		return "", &GitError{
-			Command: strings.Join(args, " "),
-			Err:     fmt.Errorf("%s: %w", string(output), err),
+			Command:  strings.Join(args, " "),
+			Err:      fmt.Errorf("%s: %w", output.String(), err),
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			ExitCode: exitCode(err),
 		}
 	}
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(output.String()), nil
 }
 
-// Commit executes git commit with the given message
-func Commit(message string) error {
-	// Validate git state before committing
-	if err := ValidateGitState(); err != nil {
-		return err
+// exitCode returns the exit status reported by err, or -1 when git did not exit
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
 	}
+	return -1
+}
 
-	// Execute commit
-	cmd := exec.Command("git", "commit", "-m", message)
+// RunAttached executes a git command attached to the process stdio
+// so that hooks, editors and credential prompts can interact with the user.
+// What git writes to stderr is also kept in the returned *GitError.
+func (r ExecRunner) RunAttached(args ...string) error {
+	cmd := r.command(args...)
+	// Keep a copy of stderr so failures can be told apart, e.g. to retry them
+	var stderr bytes.Buffer
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
 	cmd.Stdin = os.Stdin
 
 	if err := cmd.Run(); err != nil {
 		return &GitError{
-			Command: "commit",
-			Err:     err,
+			Command:  args[0],
+			Err:      err,
+			Stderr:   stderr.String(),
+			ExitCode: exitCode(err),
 		}
 	}
 
 	return nil
 }
 
+// CommitOptions configures git commit
+type CommitOptions struct {
+	Message string
+	// MessageFile is read for the message instead of Message when set
+	MessageFile string
+	// Amend replaces the tip of the current branch instead of creating a new commit
+	Amend bool
+	// NoEdit keeps the message of the amended commit; Message is ignored
+	NoEdit bool
+	// Author overrides the commit author, formatted as "Name <email>"
+	Author string
+	// Date overrides the author date in any format git understands
+	Date string
+	// NoVerify skips the pre-commit and commit-msg hooks
+	NoVerify bool
+	// Validation relaxes the git state checks run before committing
+	Validation ValidationConfig
+}
+
+// identityPattern matches a "Name <email>" identity
+var identityPattern = regexp.MustCompile(`^[^<>]*[^<>\s]\s+<[^<>\s]+>$`)
+
+// ValidateIdentity checks that identity has the "Name <email>" form git expects
+func ValidateIdentity(identity string) error {
+	if !identityPattern.MatchString(identity) {
+		return fmt.Errorf("invalid identity %q: expected \"Name <email>\"", identity)
+	}
+	return nil
+}
+
+// Validate checks the options for values git would reject
+func (o CommitOptions) Validate() error {
+	if o.NoEdit && !o.Amend {
+		return fmt.Errorf("keeping the message requires amending")
+	}
+	if o.Author != "" {
+		if err := ValidateIdentity(o.Author); err != nil {
+			return fmt.Errorf("invalid author: %w", err)
+		}
+	}
+	return nil
+}
+
+// commitArgs assembles the git arguments for the commit described by opts
+func commitArgs(opts CommitOptions) []string {
+	args := []string{"commit"}
+	if opts.NoEdit {
+		args = append(args, "--amend", "--no-edit")
+	} else {
+		if opts.MessageFile != "" {
+			args = append(args, "-F", opts.MessageFile)
+		} else {
+			args = append(args, "-m", opts.Message)
+		}
+		if opts.Amend {
+			args = append(args, "--amend")
+		}
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Date != "" {
+		args = append(args, "--date="+opts.Date)
+	}
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	return args
+}
+
+// Commit executes git commit as described by opts.
+// The message is handed to git through a file so that multiline messages
+// reach it exactly as rendered.
+func Commit(r Runner, opts CommitOptions) error {
+	// Validate git state before committing
+	if err := ValidateCommit(r, opts); err != nil {
+		return err
+	}
+
+	if !opts.NoEdit && opts.MessageFile == "" {
+		path, err := writeMessageFile(opts.Message)
+		if err != nil {
+			return fmt.Errorf("failed to write commit message: %w", err)
+		}
+		defer os.Remove(path)
+		opts.MessageFile = path
+	}
+
+	// Execute commit
+	return runAttached(r, commitArgs(opts)...)
+}
+
+// writeMessageFile stores message in a temporary file and returns its path
+func writeMessageFile(message string) (string, error) {
+	file, err := os.CreateTemp("", "tcommit-msg-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteString(message); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// DefaultRemote is the remote pushed to when none is given
+const DefaultRemote = "origin"
+
+// pushArgs assembles the git arguments pushing branch to remote
+func pushArgs(remote, branch string) []string {
+	return []string{"push", remote, branch}
+}
+
+// Push executes git push of branch to remote.
+// An empty remote defaults to DefaultRemote and an empty branch to the current branch.
+func Push(r Runner, remote, branch string) error {
+	if remote == "" {
+		remote = DefaultRemote
+	}
+	if branch == "" {
+		current, err := GetCurrentBranch(r)
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+		branch = current
+	}
+
+	return runAttached(r, pushArgs(remote, branch)...)
+}
+
 // IsGitRepository checks if the current directory is a git repository
-func IsGitRepository() error {
-	_, err := runGitCommand("rev-parse", "--is-inside-work-tree")
+func IsGitRepository(r Runner) error {
+	_, err := r.Run("rev-parse", "--is-inside-work-tree")
 	return err
 }
 
 // HasStagedChanges checks if there are any staged changes
-func HasStagedChanges() (bool, error) {
-	output, err := runGitCommand("diff", "--cached", "--name-only")
+func HasStagedChanges(r Runner) (bool, error) {
+	output, err := r.Run("diff", "--cached", "--name-only")
 	if err != nil {
 		return false, err
 	}
@@ -69,52 +294,257 @@ func HasStagedChanges() (bool, error) {
 }
 
 // HasUnstagedChanges checks if there are any unstaged changes
-func HasUnstagedChanges() (bool, error) {
-	output, err := runGitCommand("diff", "--name-only")
+func HasUnstagedChanges(r Runner) (bool, error) {
+	output, err := r.Run("diff", "--name-only")
 	if err != nil {
 		return false, err
 	}
 	return output != "", nil
 }
 
-// GetCurrentBranch returns the name of the current branch
-func GetCurrentBranch() (string, error) {
-	return runGitCommand("rev-parse", "--abbrev-ref", "HEAD")
+// splitLines splits command output into its non-empty lines
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
 
-// ValidateGitState checks if git is in a valid state for commit
-func ValidateGitState() error {
-	// Check if we're in a git repository
-	if err := IsGitRepository(); err != nil {
-		return fmt.Errorf("not a git repository: %w", err)
+// GetStagedFiles returns the paths with staged changes
+func GetStagedFiles(r Runner) ([]string, error) {
+	output, err := r.Run("diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, err
 	}
+	return splitLines(output), nil
+}
 
-	// Check for staged changes
-	hasStaged, err := HasStagedChanges()
+// GetUnstagedFiles returns the modified and untracked paths not yet staged,
+// skipping ignored files
+func GetUnstagedFiles(r Runner) ([]string, error) {
+	output, err := r.Run("ls-files", "--modified", "--others", "--exclude-standard")
 	if err != nil {
-		return fmt.Errorf("failed to check staged changes: %w", err)
+		return nil, err
 	}
-	if !hasStaged {
-		return fmt.Errorf("no staged changes to commit")
+
+	// Conflicted files are listed once per stage
+	var files []string
+	seen := make(map[string]bool)
+	for _, file := range splitLines(output) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
 	}
+	return files, nil
+}
 
-	// Check for unstaged changes
-	hasUnstaged, err := HasUnstagedChanges()
+// StageFiles adds files to the index
+func StageFiles(r Runner, files ...string) error {
+	_, err := r.Run(append([]string{"add", "--"}, files...)...)
+	return err
+}
+
+// UnstageFiles removes the staged changes of files from the index,
+// keeping them in the work tree
+func UnstageFiles(r Runner, files ...string) error {
+	_, err := r.Run(append([]string{"restore", "--staged", "--"}, files...)...)
+	return err
+}
+
+// GetRecentAuthors returns the distinct author names of the last n commits,
+// most recent first
+func GetRecentAuthors(r Runner, n int) ([]string, error) {
+	output, err := r.Run("log", "-n", strconv.Itoa(n), "--format=%an")
 	if err != nil {
-		return fmt.Errorf("failed to check unstaged changes: %w", err)
+		return nil, err
 	}
-	if hasUnstaged {
-		return fmt.Errorf("you have unstaged changes. Please stage them first or use --include")
+
+	var authors []string
+	seen := make(map[string]bool)
+	for _, author := range splitLines(output) {
+		if !seen[author] {
+			seen[author] = true
+			authors = append(authors, author)
+		}
 	}
+	return authors, nil
+}
 
-	// Get current branch
-	branch, err := GetCurrentBranch()
+// scopePattern matches the scope of a conventional commit subject, as in feat(api): ...
+var scopePattern = regexp.MustCompile(`^[a-z]+\(([^()\s]+)\)!?: `)
+
+// RecentScopes returns the distinct scopes of the conventional commits among
+// the last limit commits, most recent first. Subjects without a scope are skipped.
+func RecentScopes(r Runner, limit int) ([]string, error) {
+	output, err := r.Run("log", "-n", strconv.Itoa(limit), "--format=%s")
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return nil, err
 	}
-	if branch == "HEAD" {
-		return fmt.Errorf("detached HEAD state. Please checkout a branch")
+
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, subject := range splitLines(output) {
+		m := scopePattern.FindStringSubmatch(subject)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		scopes = append(scopes, m[1])
+	}
+	return scopes, nil
+}
+
+// GetLastCommitMessage returns the full message of the last commit
+// without its trailing newlines
+func GetLastCommitMessage(r Runner) (string, error) {
+	output, err := r.Run("log", "-1", "--format=%B")
+	if err != nil {
+		return "", err
 	}
+	return strings.TrimRight(output, "\n"), nil
+}
 
+// GetCurrentBranch returns the name of the current branch
+func GetCurrentBranch(r Runner) (string, error) {
+	return r.Run("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// GetGitConfig returns the value of a git config key such as user.name.
+// An unset key is reported as not found rather than as an error.
+func GetGitConfig(r Runner, key string) (string, bool, error) {
+	value, err := r.Run("config", "--get", key)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// git config exits with 1 when the key is not set
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// HasCommits reports whether HEAD points to a commit
+func HasCommits(r Runner) bool {
+	_, err := r.Run("rev-parse", "--verify", "-q", "HEAD")
+	return err == nil
+}
+
+// HooksDir returns the absolute path of the repository's hooks directory,
+// honoring core.hooksPath
+func HooksDir(r Runner) (string, error) {
+	return r.Run("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+}
+
+// ValidationConfig selects which checks run before a commit.
+// The zero value runs every check.
+type ValidationConfig struct {
+	// SkipRepoCheck skips verifying that the working directory is a git repository
+	SkipRepoCheck bool
+	// AllowNoStaged permits committing without staged changes
+	AllowNoStaged bool
+	// AllowUnstaged permits committing while the work tree has unstaged changes
+	AllowUnstaged bool
+	// AllowDetached permits committing on a detached HEAD
+	AllowDetached bool
+}
+
+// ValidateGitState checks if git is in a valid state for commit
+func ValidateGitState(r Runner) error {
+	return ValidateGitStateWith(r, ValidationConfig{})
+}
+
+// ValidateGitStateWith checks git state honoring cfg and returns the first failure
+func ValidateGitStateWith(r Runner, cfg ValidationConfig) error {
+	if errs := checkGitState(r, cfg); len(errs) > 0 {
+		return errs[0]
+	}
 	return nil
 }
+
+// ValidateGitStateAll runs every check and returns all failures instead of just the first
+func ValidateGitStateAll(r Runner) []error {
+	return checkGitState(r, ValidationConfig{})
+}
+
+// ValidateCommit checks opts and that git is in a valid state for the commit they describe.
+// Amending does not require staged changes since rewriting the message alone is valid.
+func ValidateCommit(r Runner, opts CommitOptions) error {
+	if errs := ValidateCommitAll(r, opts); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateCommitAll is like ValidateCommit but returns every failure
+func ValidateCommitAll(r Runner, opts CommitOptions) []error {
+	if err := opts.Validate(); err != nil {
+		return []error{err}
+	}
+	errs := checkGitState(r, opts.validation())
+	if len(errs) > 0 {
+		return errs
+	}
+	if opts.Amend && !HasCommits(r) {
+		return []error{stateError("no commit to amend")}
+	}
+	return nil
+}
+
+// validation returns the git state checks required by the commit opts describe
+func (o CommitOptions) validation() ValidationConfig {
+	cfg := o.Validation
+	if o.Amend {
+		cfg.AllowNoStaged = true
+	}
+	return cfg
+}
+
+// checkGitState runs the checks enabled by cfg and collects every failure.
+// Nothing else is checked outside of a repository.
+func checkGitState(r Runner, cfg ValidationConfig) []error {
+	var errs []error
+
+	// Check if we're in a git repository
+	if !cfg.SkipRepoCheck {
+		if err := IsGitRepository(r); err != nil {
+			return []error{fmt.Errorf("not a git repository: %w", err)}
+		}
+	}
+
+	// Check for staged changes
+	if !cfg.AllowNoStaged {
+		hasStaged, err := HasStagedChanges(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check staged changes: %w", err))
+		} else if !hasStaged {
+			errs = append(errs, stateError("no staged changes to commit"))
+		}
+	}
+
+	// Check for unstaged changes
+	if !cfg.AllowUnstaged {
+		hasUnstaged, err := HasUnstagedChanges(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check unstaged changes: %w", err))
+		} else if hasUnstaged {
+			errs = append(errs, stateError("you have unstaged changes. Please stage them first or use --allow-unstaged"))
+		}
+	}
+
+	// Check for a detached HEAD
+	if !cfg.AllowDetached {
+		branch, err := GetCurrentBranch(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get current branch: %w", err))
+		} else if branch == "HEAD" {
+			errs = append(errs, stateError("detached HEAD state. Please checkout a branch or use --allow-detached"))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,551 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a repository with one commit in a temp dir
+// and returns a runner operating on it
+func initRepo(t *testing.T) ExecRunner {
+	t.Helper()
+	r := ExecRunner{Dir: t.TempDir()}
+
+	gitCmd(t, r, "init", "-q", "-b", "main")
+	gitCmd(t, r, "config", "user.name", "Test")
+	gitCmd(t, r, "config", "user.email", "test@example.com")
+	writeFile(t, r, "README.md", "readme")
+	gitCmd(t, r, "add", "README.md")
+	gitCmd(t, r, "commit", "-q", "-m", "initial")
+
+	return r
+}
+
+// gitCmd runs git in the runner's directory, failing the test on error
+func gitCmd(t *testing.T, r ExecRunner, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func writeFile(t *testing.T, r ExecRunner, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(r.Dir, name), []byte(content), 0o644))
+}
+
+// fakeRunner answers git commands from scripted outputs and records every call
+type fakeRunner struct {
+	outputs map[string]string
+	errs    map[string]error
+	calls   []string
+	// onRun, when set, observes every call as it happens
+	onRun func(args []string)
+}
+
+func (f *fakeRunner) Run(args ...string) (string, error) {
+	call := strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	if f.onRun != nil {
+		f.onRun(args)
+	}
+	return f.outputs[call], f.errs[call]
+}
+
+// cleanState scripts a repository on branch main with staged changes only
+func cleanState() *fakeRunner {
+	return &fakeRunner{
+		outputs: map[string]string{
+			"rev-parse --is-inside-work-tree": "true",
+			"diff --cached --name-only":       "main.go",
+			"diff --name-only":                "",
+			"rev-parse --abbrev-ref HEAD":     "main",
+		},
+		errs: map[string]error{},
+	}
+}
+
+func TestValidateGitState(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  func(f *fakeRunner)
+		wantErr string
+	}{
+		{
+			name:   "Valid state",
+			script: func(f *fakeRunner) {},
+		},
+		{
+			name: "Not a repository",
+			script: func(f *fakeRunner) {
+				f.errs["rev-parse --is-inside-work-tree"] = errors.New("not a git repository")
+			},
+			wantErr: "not a git repository",
+		},
+		{
+			name: "Nothing staged",
+			script: func(f *fakeRunner) {
+				f.outputs["diff --cached --name-only"] = ""
+			},
+			wantErr: "no staged changes to commit",
+		},
+		{
+			name: "Unstaged changes",
+			script: func(f *fakeRunner) {
+				f.outputs["diff --name-only"] = "README.md"
+			},
+			wantErr: "you have unstaged changes",
+		},
+		{
+			name: "Detached HEAD",
+			script: func(f *fakeRunner) {
+				f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+			},
+			wantErr: "detached HEAD state",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := cleanState()
+			tc.script(f)
+
+			err := ValidateGitState(f)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestInvalidState(t *testing.T) {
+	f := cleanState()
+	f.outputs["diff --cached --name-only"] = ""
+	f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+
+	errs := ValidateGitStateAll(f)
+	require.Len(t, errs, 2)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrInvalidState)
+	}
+
+	// Failing to run git is not a state failure
+	f = cleanState()
+	f.errs["diff --cached --name-only"] = errors.New("boom")
+	err := ValidateGitState(f)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidState)
+}
+
+func TestValidateGitStateWithToggles(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(f *fakeRunner)
+		cfg    ValidationConfig
+		skip   string
+	}{
+		{
+			name: "Skip repository check",
+			script: func(f *fakeRunner) {
+				f.errs["rev-parse --is-inside-work-tree"] = errors.New("not a git repository")
+			},
+			cfg:  ValidationConfig{SkipRepoCheck: true},
+			skip: "rev-parse --is-inside-work-tree",
+		},
+		{
+			name: "Allow nothing staged",
+			script: func(f *fakeRunner) {
+				f.outputs["diff --cached --name-only"] = ""
+			},
+			cfg:  ValidationConfig{AllowNoStaged: true},
+			skip: "diff --cached --name-only",
+		},
+		{
+			name: "Allow unstaged changes",
+			script: func(f *fakeRunner) {
+				f.outputs["diff --name-only"] = "README.md"
+			},
+			cfg:  ValidationConfig{AllowUnstaged: true},
+			skip: "diff --name-only",
+		},
+		{
+			name: "Allow detached HEAD",
+			script: func(f *fakeRunner) {
+				f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+			},
+			cfg:  ValidationConfig{AllowDetached: true},
+			skip: "rev-parse --abbrev-ref HEAD",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// The default configuration rejects the scripted state
+			f := cleanState()
+			tc.script(f)
+			require.Error(t, ValidateGitStateWith(f, ValidationConfig{}))
+
+			f = cleanState()
+			tc.script(f)
+			require.NoError(t, ValidateGitStateWith(f, tc.cfg))
+			assert.NotContains(t, f.calls, tc.skip)
+		})
+	}
+}
+
+func TestValidateCommitUsesValidationConfig(t *testing.T) {
+	f := cleanState()
+	f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+
+	require.Error(t, ValidateCommit(f, CommitOptions{}))
+	require.NoError(t, ValidateCommit(f, CommitOptions{Validation: ValidationConfig{AllowDetached: true}}))
+}
+
+func TestValidateGitStateAll(t *testing.T) {
+	f := cleanState()
+	f.outputs["diff --cached --name-only"] = ""
+	f.outputs["diff --name-only"] = "README.md"
+	f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+
+	errs := ValidateGitStateAll(f)
+	require.Len(t, errs, 3)
+	assert.ErrorContains(t, errs[0], "no staged changes to commit")
+	assert.ErrorContains(t, errs[1], "you have unstaged changes")
+	assert.ErrorContains(t, errs[2], "detached HEAD state")
+
+	// The first failure is the one ValidateGitState reports
+	assert.Equal(t, errs[0], ValidateGitState(f))
+}
+
+func TestValidateGitStateAllOutsideRepository(t *testing.T) {
+	f := cleanState()
+	f.errs["rev-parse --is-inside-work-tree"] = errors.New("not a git repository")
+
+	errs := ValidateGitStateAll(f)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "not a git repository")
+	assert.Len(t, f.calls, 1)
+}
+
+func TestValidateGitStateAllClean(t *testing.T) {
+	assert.Empty(t, ValidateGitStateAll(cleanState()))
+}
+
+func TestValidateCommitAll(t *testing.T) {
+	f := cleanState()
+	f.outputs["diff --cached --name-only"] = ""
+	f.outputs["rev-parse --abbrev-ref HEAD"] = "HEAD"
+
+	assert.Len(t, ValidateCommitAll(f, CommitOptions{}), 2)
+	assert.Len(t, ValidateCommitAll(f, CommitOptions{Amend: true}), 1)
+
+	errs := ValidateCommitAll(f, CommitOptions{Author: "Jane"})
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "invalid author")
+}
+
+func TestValidateCommitAmendWithoutStaged(t *testing.T) {
+	f := cleanState()
+	f.outputs["diff --cached --name-only"] = ""
+
+	require.Error(t, ValidateCommit(f, CommitOptions{}))
+	require.NoError(t, ValidateCommit(f, CommitOptions{Amend: true}))
+}
+
+func TestCommitWithFakeRunner(t *testing.T) {
+	f := cleanState()
+	message := "feat: add login\n\nSupports \"quoted\" text\nand several lines\n"
+
+	var committed string
+	f.onRun = func(args []string) {
+		if args[0] == "commit" {
+			require.Equal(t, "-F", args[1])
+			data, err := os.ReadFile(args[2])
+			require.NoError(t, err)
+			committed = string(data)
+		}
+	}
+
+	require.NoError(t, Commit(f, CommitOptions{Message: message}))
+	assert.Equal(t, message, committed)
+
+	// The message file is removed once committed
+	last := strings.Fields(f.calls[len(f.calls)-1])
+	assert.NoFileExists(t, last[2])
+}
+
+func TestPushArgs(t *testing.T) {
+	assert.Equal(t, []string{"push", "origin", "main"}, pushArgs("origin", "main"))
+	assert.Equal(t, []string{"push", "upstream", "feature/login"}, pushArgs("upstream", "feature/login"))
+}
+
+func TestCommitArgs(t *testing.T) {
+	assert.Equal(t, []string{"commit", "-m", "msg"}, commitArgs(CommitOptions{Message: "msg"}))
+	assert.Equal(t, []string{"commit", "-m", "msg", "--amend"}, commitArgs(CommitOptions{Message: "msg", Amend: true}))
+	assert.Equal(t, []string{"commit", "-F", "msg.txt"}, commitArgs(CommitOptions{Message: "msg", MessageFile: "msg.txt"}))
+	assert.Equal(t, []string{"commit", "--amend", "--no-edit"}, commitArgs(CommitOptions{Message: "msg", Amend: true, NoEdit: true}))
+	assert.Equal(t, []string{"commit", "-m", "msg", "--no-verify"}, commitArgs(CommitOptions{Message: "msg", NoVerify: true}))
+	assert.NotContains(t, commitArgs(CommitOptions{Message: "msg", Amend: true}), "--no-verify")
+	assert.Error(t, CommitOptions{NoEdit: true}.Validate())
+}
+
+func TestCommitArgsIdentity(t *testing.T) {
+	args := commitArgs(CommitOptions{
+		Message: "msg",
+		Author:  "Jane Doe <jane@example.com>",
+		Date:    "2024-01-02T03:04:05",
+	})
+	assert.Equal(t, []string{
+		"commit", "-m", "msg",
+		"--author=Jane Doe <jane@example.com>",
+		"--date=2024-01-02T03:04:05",
+	}, args)
+}
+
+func TestValidateIdentity(t *testing.T) {
+	valid := []string{
+		"Jane Doe <jane@example.com>",
+		"jane <jane@localhost>",
+	}
+	for _, identity := range valid {
+		assert.NoError(t, ValidateIdentity(identity), identity)
+	}
+
+	invalid := []string{
+		"",
+		"Jane Doe",
+		"<jane@example.com>",
+		"Jane Doe jane@example.com",
+		"Jane <jane@example.com> extra",
+		"Jane <jane doe@example.com>",
+	}
+	for _, identity := range invalid {
+		assert.Error(t, ValidateIdentity(identity), identity)
+	}
+}
+
+func TestCommitRejectsInvalidAuthor(t *testing.T) {
+	// Outside of any repository the author check must fail first
+	err := Commit(ExecRunner{Dir: t.TempDir()}, CommitOptions{Message: "msg", Author: "Jane Doe"})
+	assert.ErrorContains(t, err, "invalid author")
+}
+
+func TestCommitAmend(t *testing.T) {
+	r := initRepo(t)
+
+	// Nothing is staged, so only an amend is valid
+	require.Error(t, ValidateGitState(r))
+
+	tmpl, err := template.ParseString("{{.type}}: {{.desc}}")
+	require.NoError(t, err)
+	message, err := tmpl.Execute(template.ReplacerFuncFromMap(map[string]string{
+		"type": "docs",
+		"desc": "add readme",
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, Commit(r, CommitOptions{Message: message, Amend: true}))
+
+	assert.Equal(t, "docs: add readme\n\n", gitCmd(t, r, "log", "-1", "--format=%B"))
+	assert.Equal(t, "1\n", gitCmd(t, r, "rev-list", "--count", "HEAD"))
+}
+
+func TestCommitAmendNoEdit(t *testing.T) {
+	r := initRepo(t)
+
+	writeFile(t, r, "forgotten.txt", "forgotten")
+	gitCmd(t, r, "add", "forgotten.txt")
+
+	require.NoError(t, Commit(r, CommitOptions{Amend: true, NoEdit: true}))
+
+	assert.Equal(t, "initial\n\n", gitCmd(t, r, "log", "-1", "--format=%B"))
+	assert.Equal(t, "1\n", gitCmd(t, r, "rev-list", "--count", "HEAD"))
+	assert.Equal(t, "README.md\nforgotten.txt\n", gitCmd(t, r, "ls-tree", "--name-only", "HEAD"))
+}
+
+func TestCommitAmendWithoutCommits(t *testing.T) {
+	r := ExecRunner{Dir: t.TempDir()}
+	gitCmd(t, r, "init", "-q", "-b", "main")
+	writeFile(t, r, "README.md", "readme")
+	gitCmd(t, r, "add", "README.md")
+
+	err := ValidateCommit(r, CommitOptions{Amend: true, NoEdit: true, Validation: ValidationConfig{AllowDetached: true}})
+	assert.ErrorContains(t, err, "no commit to amend")
+}
+
+func TestStagingFiles(t *testing.T) {
+	r := initRepo(t)
+
+	writeFile(t, r, "README.md", "changed")
+	writeFile(t, r, "new.txt", "new")
+	writeFile(t, r, ".gitignore", "*.log\n")
+	writeFile(t, r, "debug.log", "ignored")
+
+	unstaged, err := GetUnstagedFiles(r)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".gitignore", "README.md", "new.txt"}, unstaged)
+
+	require.NoError(t, StageFiles(r, "README.md", "new.txt"))
+
+	staged, err := GetStagedFiles(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "new.txt"}, staged)
+
+	unstaged, err = GetUnstagedFiles(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore"}, unstaged)
+
+	require.NoError(t, UnstageFiles(r, "README.md"))
+
+	staged, err = GetStagedFiles(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new.txt"}, staged)
+}
+
+func TestRunnerDir(t *testing.T) {
+	r := initRepo(t)
+
+	require.NoError(t, IsGitRepository(r))
+	assert.Error(t, IsGitRepository(ExecRunner{Dir: t.TempDir()}))
+
+	branch, err := GetCurrentBranch(r)
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	writeFile(t, r, "new.txt", "new")
+	gitCmd(t, r, "add", "new.txt")
+
+	staged, err := HasStagedChanges(r)
+	require.NoError(t, err)
+	assert.True(t, staged)
+
+	require.NoError(t, Commit(r, CommitOptions{Message: "feat: add new file"}))
+	assert.Equal(t, "feat: add new file\n\n", gitCmd(t, r, "log", "-1", "--format=%B"))
+}
+
+func TestExecRunnerBin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub git binary is a shell script")
+	}
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	stub := filepath.Join(dir, "fake-git")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\necho stub-output\n"
+	require.NoError(t, os.WriteFile(stub, []byte(script), 0o755))
+
+	r := ExecRunner{Dir: dir, Bin: stub}
+	branch, err := GetCurrentBranch(r)
+	require.NoError(t, err)
+	assert.Equal(t, "stub-output", branch)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "rev-parse --abbrev-ref HEAD\n", string(args))
+}
+
+func TestExecRunnerGitError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub git binary is a shell script")
+	}
+
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "fake-git")
+	script := "#!/bin/sh\necho checking message\necho 'hook rejected the commit' >&2\nexit 3\n"
+	require.NoError(t, os.WriteFile(stub, []byte(script), 0o755))
+
+	_, err := ExecRunner{Dir: dir, Bin: stub}.Run("commit", "-m", "msg")
+	var gitErr *GitError
+	require.ErrorAs(t, err, &gitErr)
+	assert.Equal(t, "commit -m msg", gitErr.Command)
+	assert.Equal(t, "checking message\n", gitErr.Stdout)
+	assert.Equal(t, "hook rejected the commit\n", gitErr.Stderr)
+	assert.Equal(t, 3, gitErr.ExitCode)
+	assert.Contains(t, err.Error(), "hook rejected the commit")
+	assert.Contains(t, err.Error(), "exit status 3")
+
+	_, err = ExecRunner{Dir: dir, Bin: filepath.Join(dir, "missing-git")}.Run("status")
+	require.ErrorAs(t, err, &gitErr)
+	assert.Equal(t, -1, gitErr.ExitCode)
+}
+
+func TestGetGitConfig(t *testing.T) {
+	// Ignore the user's and the system's config
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	r := initRepo(t)
+
+	name, found, err := GetGitConfig(r, "user.name")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Test", name)
+
+	gitCmd(t, r, "config", "--unset", "user.email")
+	_, found, err = GetGitConfig(r, "user.email")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Failing to run git is an error
+	_, _, err = GetGitConfig(ExecRunner{Dir: r.Dir, Bin: filepath.Join(r.Dir, "missing-git")}, "user.name")
+	assert.Error(t, err)
+}
+
+func TestGetRecentAuthors(t *testing.T) {
+	r := &fakeRunner{outputs: map[string]string{
+		"log -n 50 --format=%an": "Jane\nBob\nJane\n",
+	}}
+	authors, err := GetRecentAuthors(r, 50)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Jane", "Bob"}, authors)
+}
+
+func TestGetLastCommitMessage(t *testing.T) {
+	r := initRepo(t)
+	writeFile(t, r, "a.txt", "a")
+	gitCmd(t, r, "add", "a.txt")
+	gitCmd(t, r, "commit", "-q", "-m", "feat(api): add a\n\nBody line\n\nRefs: #1")
+
+	message, err := GetLastCommitMessage(r)
+	require.NoError(t, err)
+	assert.Equal(t, "feat(api): add a\n\nBody line\n\nRefs: #1", message)
+
+	fake := &fakeRunner{outputs: map[string]string{"log -1 --format=%B": "fix: y\n\n"}}
+	message, err = GetLastCommitMessage(fake)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: y", message)
+
+	_, err = GetLastCommitMessage(ExecRunner{Dir: t.TempDir()})
+	assert.Error(t, err)
+}
+
+func TestRecentScopes(t *testing.T) {
+	r := &fakeRunner{outputs: map[string]string{
+		"log -n 20 --format=%s": "feat(api): add login\n" +
+			"fix: typo\n" +
+			"chore(deps)!: bump go\n" +
+			"fix(api): handle nil\n" +
+			"Merge branch 'main'\n" +
+			"docs(readme) missing colon\n" +
+			"refactor(cli): split root\n",
+	}}
+	scopes, err := RecentScopes(r, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "deps", "cli"}, scopes)
+
+	r = &fakeRunner{errs: map[string]error{"log -n 5 --format=%s": errors.New("no commits")}}
+	_, err = RecentScopes(r, 5)
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// DefaultRetryPatterns match the messages of failures worth retrying:
+// a lock held by another git process and network hiccups
+var DefaultRetryPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`index\.lock`),
+	regexp.MustCompile(`(?i)could not resolve host`),
+	regexp.MustCompile(`(?i)connection (reset|refused|timed out)`),
+	regexp.MustCompile(`(?i)the remote end hung up unexpectedly`),
+}
+
+// RetryPolicy configures the retries of a RetryRunner
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries including the first; below 2 disables retries
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled before each further one
+	Backoff time.Duration
+	// Patterns select the failures that are retried by their message;
+	// empty retries every failure
+	Patterns []*regexp.Regexp
+}
+
+// retryable lists the git commands a RetryRunner retries
+var retryable = map[string]bool{
+	"commit": true,
+	"push":   true,
+}
+
+// RetryRunner retries failed commit and push commands of the wrapped runner
+// according to Policy. Other commands run once.
+type RetryRunner struct {
+	Runner
+	Policy RetryPolicy
+	// Sleep waits between attempts; nil means time.Sleep
+	Sleep func(time.Duration)
+}
+
+// Run executes a git command, retrying transient failures of commit and push
+func (r RetryRunner) Run(args ...string) (string, error) {
+	var output string
+	err := r.retry(args, func() error {
+		var err error
+		output, err = r.Runner.Run(args...)
+		return err
+	})
+	return output, err
+}
+
+// RunAttached executes a git command attached to the stdio, retrying like Run.
+func (r RetryRunner) RunAttached(args ...string) error {
+	return r.retry(args, func() error {
+		return runAttached(r.Runner, args...)
+	})
+}
+
+// retry calls run until it succeeds, fails permanently or runs out of attempts
+func (r RetryRunner) retry(args []string, run func() error) error {
+	sleep := r.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	backoff := r.Policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= r.Policy.MaxAttempts || len(args) == 0 || !retryable[args[0]] || !r.Policy.matches(err) {
+			return err
+		}
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// matches reports whether err is one of the failures the policy retries,
+// judging by its message and what git wrote to stderr
+func (p RetryPolicy) matches(err error) bool {
+	if len(p.Patterns) == 0 {
+		return true
+	}
+	message := err.Error()
+	var gerr *GitError
+	if errors.As(err, &gerr) {
+		message += "\n" + gerr.Stderr
+	}
+	for _, pattern := range p.Patterns {
+		if pattern.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyRunner fails its first failures calls with err
+type flakyRunner struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyRunner) Run(args ...string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", f.err
+	}
+	return "ok", nil
+}
+
+func TestRetryRunner(t *testing.T) {
+	lockErr := errors.New("fatal: Unable to create '.git/index.lock': File exists")
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Second, Patterns: DefaultRetryPatterns}
+
+	tests := []struct {
+		name     string
+		args     []string
+		failures int
+		err      error
+		calls    int
+		waits    []time.Duration
+		wantErr  bool
+	}{
+		{name: "Succeeds after retries", args: []string{"commit"}, failures: 2, err: lockErr, calls: 3, waits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "Gives up", args: []string{"push"}, failures: 5, err: lockErr, calls: 3, waits: []time.Duration{time.Second, 2 * time.Second}, wantErr: true},
+		{name: "Permanent failure", args: []string{"commit"}, failures: 1, err: errors.New("nothing to commit"), calls: 1, wantErr: true},
+		{name: "Other commands run once", args: []string{"status"}, failures: 1, err: lockErr, calls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &flakyRunner{failures: tt.failures, err: tt.err}
+			var waits []time.Duration
+			r := RetryRunner{Runner: fake, Policy: policy, Sleep: func(d time.Duration) { waits = append(waits, d) }}
+
+			output, err := r.Run(tt.args...)
+			assert.Equal(t, tt.calls, fake.calls)
+			assert.Equal(t, tt.waits, waits)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ok", output)
+		})
+	}
+}
+
+func TestRetryRunnerAttached(t *testing.T) {
+	fake := &flakyRunner{failures: 1, err: errors.New("exit status 128")}
+	r := RetryRunner{
+		Runner: fake,
+		Policy: RetryPolicy{MaxAttempts: 2, Patterns: []*regexp.Regexp{regexp.MustCompile(`status 128`)}},
+		Sleep:  func(time.Duration) {},
+	}
+	require.NoError(t, r.RunAttached("push"))
+	assert.Equal(t, 2, fake.calls)
+
+	// Without patterns every failure is retried, and MaxAttempts below 2 disables retries
+	fake = &flakyRunner{failures: 1, err: errors.New("anything")}
+	r = RetryRunner{Runner: fake, Policy: RetryPolicy{MaxAttempts: 1}, Sleep: func(time.Duration) {}}
+	assert.Error(t, r.RunAttached("commit"))
+	assert.Equal(t, 1, fake.calls)
+}
+
+func TestRetryRunnerHeldIndexLock(t *testing.T) {
+	r := initRepo(t)
+	writeFile(t, r, "a.txt", "a")
+	gitCmd(t, r, "add", "a.txt")
+	lock := filepath.Join(r.Dir, ".git", "index.lock")
+	require.NoError(t, os.WriteFile(lock, nil, 0o644))
+
+	// Without retries the held lock fails the commit, as git reports on stderr
+	err := Commit(r, CommitOptions{Message: "feat: a"})
+	var gerr *GitError
+	require.ErrorAs(t, err, &gerr)
+	assert.Contains(t, gerr.Stderr, "index.lock")
+
+	// The other git process releases the lock while waiting to retry
+	var waits int
+	retrying := RetryRunner{
+		Runner: r,
+		Policy: RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Patterns: DefaultRetryPatterns},
+		Sleep: func(time.Duration) {
+			waits++
+			require.NoError(t, os.Remove(lock))
+		},
+	}
+	require.NoError(t, Commit(retrying, CommitOptions{Message: "feat: a"}))
+	assert.Equal(t, 1, waits)
+	assert.Equal(t, "feat: a\n\n", gitCmd(t, r, "log", "-1", "--format=%B"))
+}
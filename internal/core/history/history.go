@@ -0,0 +1,106 @@
+// Package history remembers the values last entered for each template
+// so they can be offered again on the next run.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// fileName is the name of the state file inside the config directory
+const fileName = "state.json"
+
+// State holds the last used values of each template, keyed by the template's absolute path
+type State map[string]map[string]string
+
+// Dir returns the directory tcommit keeps its state in:
+// $XDG_CONFIG_HOME/tcommit (~/.config/tcommit by default)
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "tcommit"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "tcommit"), nil
+}
+
+// DefaultPath returns the state file in Dir
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Load reads the state file at path. A missing file is an empty state.
+func Load(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := State{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open loads the state file at path, or at DefaultPath when path is empty,
+// and returns the state together with the path to save it to
+func Open(path string) (State, string, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, "", err
+		}
+	}
+	s, err := Load(path)
+	return s, path, err
+}
+
+// Save writes the state to path, creating its directory if needed
+func (s State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Values returns the values last used for the template at path
+func (s State) Values(path string) map[string]string {
+	return s[key(path)]
+}
+
+// Remember records values as the last used values of the template at path.
+// Empty values are not remembered.
+func (s State) Remember(path string, values map[string]string) {
+	remembered := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != "" {
+			remembered[k] = v
+		}
+	}
+	s[key(path)] = remembered
+}
+
+// key identifies a template independently of the working directory
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
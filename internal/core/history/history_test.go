@@ -0,0 +1,62 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tcommit", "state.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, s)
+
+	s.Remember("feat.tmpl", map[string]string{"type": "feat", "scope": "core", "desc": ""})
+	require.NoError(t, s.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "feat", "scope": "core"}, loaded.Values("feat.tmpl"))
+
+	// Templates are keyed by absolute path
+	abs, err := filepath.Abs("feat.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, loaded.Values("feat.tmpl"), loaded.Values(abs))
+	assert.Nil(t, loaded.Values("fix.tmpl"))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+
+	_, err := Load(path)
+	assert.Error(t, err)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+
+	path, err := DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/config", "tcommit", "state.json"), path)
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	s, path, err := Open("")
+	require.NoError(t, err)
+	assert.Empty(t, s)
+	assert.Equal(t, filepath.Join(dir, "tcommit", "state.json"), path)
+
+	custom := filepath.Join(dir, "custom.json")
+	_, path, err = Open(custom)
+	require.NoError(t, err)
+	assert.Equal(t, custom, path)
+}
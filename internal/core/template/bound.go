@@ -0,0 +1,54 @@
+package template
+
+import (
+	"bytes"
+	"io"
+)
+
+// BoundTemplate is a template bound to a fixed Replacer.
+// It implements io.WriterTo and io.Reader so it can be passed to io.Copy,
+// which streams it through WriteTo, e.g. into a file or an http.ResponseWriter.
+type BoundTemplate struct {
+	t *Template
+	r Replacer
+
+	// rendered backs Read, which renders the whole template on first use
+	rendered *bytes.Reader
+}
+
+// Bind returns the template bound to r.
+func (t *Template) Bind(r Replacer) *BoundTemplate {
+	return &BoundTemplate{t: t, r: r}
+}
+
+// WriteTo renders the template to w and returns the number of bytes written.
+func (b *BoundTemplate) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := b.t.ExecuteTo(cw, b.r)
+	return cw.n, err
+}
+
+// Read reads the rendered template. The template is rendered once into memory
+// on the first call; prefer WriteTo to stream it.
+func (b *BoundTemplate) Read(p []byte) (int, error) {
+	if b.rendered == nil {
+		data, err := b.t.ExecuteBytes(b.r)
+		if err != nil {
+			return 0, err
+		}
+		b.rendered = bytes.NewReader(data)
+	}
+	return b.rendered.Read(p)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
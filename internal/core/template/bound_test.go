@@ -0,0 +1,41 @@
+package template
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindCopy(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat|@fix}}({{.scope:@core}}): {{.desc}}")
+	require.NoError(t, err)
+	r := ReplacerFuncFromMap(map[string]string{"desc": "add login"})
+
+	want, err := tmpl.Execute(r)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, tmpl.Bind(r))
+	require.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+	assert.Equal(t, int64(len(want)), n)
+
+	// Reading goes through the same rendering
+	require.NoError(t, iotest.TestReader(tmpl.Bind(r), []byte(want)))
+}
+
+func TestBindError(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat|fix}}")
+	require.NoError(t, err)
+	bound := tmpl.Bind(ReplacerFuncFromMap(map[string]string{"type": "docs"}))
+
+	_, err = io.Copy(io.Discard, bound)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	_, err = io.ReadAll(bound)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Cache holds parsed templates keyed by file path.
+// A file is re-parsed when its modification time changes; changes to
+// files it includes are not tracked. It is safe for concurrent use.
+// The returned templates are shared; Clone them before modifying their nodes.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry is a parsed template together with the mtime it was parsed at.
+type cacheEntry struct {
+	modTime time.Time
+	tmpl    *Template
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the parsed template for path, parsing it on first access
+// and whenever the file's modification time differs from the cached one.
+func (c *Cache) Get(path string) (*Template, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[path]; ok && entry.modTime.Equal(info.ModTime()) {
+		return entry.tmpl, nil
+	}
+
+	tmpl, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.entries[path] = cacheEntry{
+		modTime: info.ModTime(),
+		tmpl:    tmpl,
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,75 @@
+package template
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheServesUnchangedFile(t *testing.T) {
+	path := writeTemp(t, "commit.tmpl", "Hello {{.name}}!")
+	cache := NewCache()
+
+	first, err := cache.Get(path)
+	require.NoError(t, err)
+
+	second, err := cache.Get(path)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestCacheReparsesModifiedFile(t *testing.T) {
+	path := writeTemp(t, "commit.tmpl", "Hello {{.name}}!")
+	cache := NewCache()
+
+	first, err := cache.Get(path)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("Bye {{.name}}!"), 0o644))
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	second, err := cache.Get(path)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	got, err := second.Execute(ReplacerFuncFromMap(map[string]string{"name": "World"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Bye World!", got)
+}
+
+func TestCacheErrors(t *testing.T) {
+	cache := NewCache()
+
+	_, err := cache.Get(writeTemp(t, "missing.tmpl", "") + ".nope")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = cache.Get(writeTemp(t, "bad.tmpl", "Hello {{name}}!"))
+	require.ErrorIs(t, err, ErrInvalidTokenSyntax)
+}
+
+func TestCacheConcurrentGet(t *testing.T) {
+	path := writeTemp(t, "commit.tmpl", "Hello {{.name}}!")
+	cache := NewCache()
+
+	var wg sync.WaitGroup
+	results := make([]*Template, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tmpl, err := cache.Get(path)
+			assert.NoError(t, err)
+			results[i] = tmpl
+		}()
+	}
+	wg.Wait()
+
+	for _, tmpl := range results {
+		assert.Same(t, results[0], tmpl)
+	}
+}
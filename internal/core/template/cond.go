@@ -0,0 +1,183 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Block keywords: {{if .key}}...{{else}}...{{end}}
+const (
+	ifKeyword   = "if"
+	elseKeyword = "else"
+	endKeyword  = "end"
+)
+
+// Boolean values accepted by conditions, compared ignoring case.
+// An empty value is false, like a variable left empty.
+var (
+	truthy = []string{"true", "1", "yes", "y", "on"}
+	falsy  = []string{"false", "0", "no", "n", "off", ""}
+)
+
+// ParseBool interprets s as a yes/no answer using the truthiness rules of
+// {{if}} conditions: true, 1, yes, y and on are true, false, 0, no, n, off
+// and the empty string are false, ignoring case and surrounding whitespace.
+// Other values are an error.
+func ParseBool(s string) (bool, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	for _, t := range truthy {
+		if v == t {
+			return true, nil
+		}
+	}
+	for _, f := range falsy {
+		if v == f {
+			return false, nil
+		}
+	}
+	return false, NewNotBooleanError(s)
+}
+
+// IfNode renders Then when its condition is true and Else otherwise.
+// An optional condition, {{if .scope?}}, only tests that its value is non-empty,
+// so that a scope such as "no" is kept.
+type IfNode struct {
+	// Cond is rendered like any variable, with its constraints, default and
+	// filters, and its value read with ParseBool
+	Cond *VarNode
+	Then []Node
+	Else []Node
+}
+
+// WriteTo writes the branch selected by the condition to w, using replacements.
+func (n *IfNode) WriteTo(w io.Writer, r Replacer) error {
+	var cond strings.Builder
+	if err := n.Cond.WriteTo(&cond, r); err != nil {
+		return err
+	}
+	if p, ok := r.(placeholderReplacer); ok && cond.String() == p.placeholder(n.Cond.Key) {
+		// A preview cannot tell which branch an unfilled condition selects
+		_, err := io.WriteString(w, cond.String())
+		return err
+	}
+
+	ok := cond.Len() > 0
+	if !n.Cond.Optional {
+		var err error
+		if ok, err = ParseBool(cond.String()); err != nil {
+			return NewConditionError(n.Cond.Key, err)
+		}
+	}
+
+	branch := n.Else
+	if ok {
+		branch = n.Then
+	}
+	for _, node := range branch {
+		if err := node.WriteTo(w, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the source of the block in canonical form.
+func (n *IfNode) String() string {
+	var b strings.Builder
+	cond := n.Cond.String()
+	b.WriteString(openMarker + ifKeyword + " " + cond[len(openMarker):])
+	writeNodes(&b, n.Then)
+	if n.Else != nil {
+		b.WriteString(openMarker + elseKeyword + closeMarker)
+		writeNodes(&b, n.Else)
+	}
+	b.WriteString(openMarker + endKeyword + closeMarker)
+	return b.String()
+}
+
+// blockParser nests the nodes of if blocks while parsing.
+// Parsed nodes go to nodes: the template itself or the branch of the innermost open block.
+type blockParser struct {
+	nodes []Node
+	open  []openBlock
+}
+
+// openBlock is an if block whose end has not been parsed yet
+type openBlock struct {
+	node   *IfNode
+	parent []Node
+	inElse bool
+	// at locates the if token, to report the block left open
+	at *ParseError
+}
+
+// block handles token if it is an if, else or end keyword and reports whether it was one.
+// locate returns the position of the token.
+func (p *blockParser) block(token string, locate func() *ParseError) (bool, error) {
+	t := strings.TrimSpace(token)
+	switch {
+	case strings.HasPrefix(t, ifKeyword+" "):
+		cond, err := parseToken(strings.TrimSpace(t[len(ifKeyword):]))
+		if err != nil {
+			return true, err
+		}
+		v, ok := cond.(*VarNode)
+		if !ok {
+			return true, NewInvalidTokenSyntaxError(token)
+		}
+		p.open = append(p.open, openBlock{node: &IfNode{Cond: v}, parent: p.nodes, at: locate()})
+		p.nodes = nil
+	case t == elseKeyword:
+		if len(p.open) == 0 || p.open[len(p.open)-1].inElse {
+			return true, NewUnexpectedKeywordError(t)
+		}
+		b := &p.open[len(p.open)-1]
+		b.node.Then = p.nodes
+		b.inElse = true
+		p.nodes = nil
+	case t == endKeyword:
+		if len(p.open) == 0 {
+			return true, NewUnexpectedKeywordError(t)
+		}
+		b := p.open[len(p.open)-1]
+		p.open = p.open[:len(p.open)-1]
+		if b.inElse {
+			b.node.Else = emptyIfNil(p.nodes)
+		} else {
+			b.node.Then = p.nodes
+		}
+		b.node.Then = emptyIfNil(b.node.Then)
+		p.nodes = append(b.parent, b.node)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// finish returns the parsed nodes, failing if a block was left open
+func (p *blockParser) finish() ([]Node, error) {
+	if len(p.open) > 0 {
+		at := p.open[len(p.open)-1].at
+		at.Err = ErrUnclosedIf
+		return nil, at
+	}
+	return p.nodes, nil
+}
+
+// emptyIfNil returns an empty branch for nil so parsed branches compare equal
+func emptyIfNil(nodes []Node) []Node {
+	if nodes == nil {
+		return []Node{}
+	}
+	return nodes
+}
+
+// writeNodes writes the source of nodes to b
+func writeNodes(b *strings.Builder, nodes []Node) {
+	for _, node := range nodes {
+		if s, ok := node.(fmt.Stringer); ok {
+			b.WriteString(s.String())
+		}
+	}
+}
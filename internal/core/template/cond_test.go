@@ -0,0 +1,181 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"true", "1", "yes", "Y", " on ", "TRUE"} {
+		got, err := ParseBool(s)
+		require.NoError(t, err, s)
+		assert.True(t, got, s)
+	}
+	for _, s := range []string{"false", "0", "no", "N", "Off", ""} {
+		got, err := ParseBool(s)
+		require.NoError(t, err, s)
+		assert.False(t, got, s)
+	}
+
+	_, err := ParseBool("maybe")
+	assert.ErrorIs(t, err, ErrNotBoolean)
+}
+
+func TestIfBlock(t *testing.T) {
+	tmpl, err := ParseString("{{.type}}{{if .breaking:true|false|@false}}!{{end}}: {{.desc}}" +
+		"{{if .breaking:true|false|@false}}\n\nBREAKING CHANGE: {{.note}}{{else}}\n\nRefs: {{.issue?}}{{end}}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   string
+	}{
+		{
+			name:   "True",
+			values: map[string]string{"type": "feat", "breaking": "true", "desc": "drop v1", "note": "v1 is gone"},
+			want:   "feat!: drop v1\n\nBREAKING CHANGE: v1 is gone",
+		},
+		{
+			name:   "False",
+			values: map[string]string{"type": "fix", "breaking": "false", "desc": "typo", "issue": "#1"},
+			want:   "fix: typo\n\nRefs: #1",
+		},
+		{
+			name:   "Default",
+			values: map[string]string{"type": "fix", "desc": "typo"},
+			want:   "fix: typo\n\nRefs: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.Execute(ReplacerFuncFromMap(tt.values))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// The unselected branch needs no values
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "fix", "desc": "typo", "breaking": "true", "note": "x"}))
+	require.NoError(t, err)
+	assert.Equal(t, "fix!: typo\n\nBREAKING CHANGE: x", got)
+
+	keys := make([]string, 0)
+	for _, v := range tmpl.Variables() {
+		keys = append(keys, v.Key)
+	}
+	assert.Equal(t, []string{"type", "breaking", "desc", "breaking", "note", "issue"}, keys)
+}
+
+func TestNestedIfBlocks(t *testing.T) {
+	tmpl, err := ParseString("{{if .a}}A{{if .b}}B{{else}}b{{end}}{{else}}-{{end}}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"yes", "yes", "AB"},
+		{"yes", "no", "Ab"},
+		{"no", "yes", "-"},
+	}
+	for _, tt := range tests {
+		got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"a": tt.a, "b": tt.b}))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestIfBlockErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want error
+	}{
+		{"{{if .a}}open", ErrUnclosedIf},
+		{"{{if .a}}{{if .b}}{{end}}", ErrUnclosedIf},
+		{"text{{else}}", ErrUnexpectedKeyword},
+		{"text{{end}}", ErrUnexpectedKeyword},
+		{"{{if .a}}x{{else}}y{{else}}z{{end}}", ErrUnexpectedKeyword},
+		{`{{if date "2006"}}x{{end}}`, ErrInvalidTokenSyntax},
+	}
+	for _, tt := range tests {
+		_, err := ParseString(tt.src)
+		assert.ErrorIs(t, err, tt.want, tt.src)
+
+		_, err = ParseStream(strings.NewReader(tt.src))
+		assert.ErrorIs(t, err, tt.want, tt.src)
+	}
+
+	// An unclosed block is reported at its if
+	_, err := ParseString("line\n{{if .a}}x")
+	var perr *ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 2, perr.Line)
+	assert.Equal(t, 1, perr.Column)
+
+	tmpl, err := ParseString("{{if .a}}x{{end}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"a": "maybe"}))
+	assert.ErrorIs(t, err, ErrNotBoolean)
+	assert.ErrorContains(t, err, `condition "a"`)
+}
+
+func TestOptionalCondition(t *testing.T) {
+	tmpl, err := ParseString("feat{{if .scope?}}({{.scope}}){{end}}: x")
+	require.NoError(t, err)
+
+	tests := []struct {
+		values map[string]string
+		want   string
+	}{
+		{map[string]string{"scope": "api"}, "feat(api): x"},
+		{map[string]string{"scope": ""}, "feat: x"},
+		{map[string]string{}, "feat: x"},
+		{map[string]string{"scope": "no"}, "feat(no): x"},
+		{map[string]string{"scope": "0"}, "feat(0): x"},
+	}
+	for _, tt := range tests {
+		got, err := tmpl.Execute(ReplacerFuncFromMap(tt.values))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestPreviewCondition(t *testing.T) {
+	tmpl, err := ParseString("{{.type}}{{if .breaking}}!{{end}}: {{.desc}}")
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(PreviewReplacer(ReplacerFuncFromMap(map[string]string{"type": "feat"})))
+	require.NoError(t, err)
+	assert.Equal(t, "feat<breaking?>: <desc?>", got)
+
+	got, err = tmpl.Execute(PreviewReplacer(ReplacerFuncFromMap(map[string]string{"type": "feat", "breaking": "yes"})))
+	require.NoError(t, err)
+	assert.Equal(t, "feat!: <desc?>", got)
+}
+
+func TestIfBlockRoundTrip(t *testing.T) {
+	src := "{{if .a:yes|@no}}x{{.b}}{{else}}{{if .c?}}y{{end}}{{end}} {{ if .d }}{{ end }}"
+	tmpl, err := ParseString(src)
+	require.NoError(t, err)
+	assert.Equal(t, "{{if .a:yes|@no}}x{{.b}}{{else}}{{if .c?}}y{{end}}{{end}} {{if .d}}{{end}}", tmpl.String())
+
+	reparsed, err := ParseString(tmpl.String())
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.Nodes, reparsed.Nodes)
+
+	streamed, err := ParseStream(strings.NewReader(src))
+	require.NoError(t, err)
+	assert.Equal(t, tmpl, streamed)
+
+	// Clones do not share branches
+	clone := tmpl.Clone()
+	assert.Equal(t, tmpl.Nodes, clone.Nodes)
+	clone.Nodes[0].(*IfNode).Cond.Default = "yes"
+	clone.Nodes[0].(*IfNode).Then[0].(*TextNode).Text = "z"
+	assert.Equal(t, "no", tmpl.Nodes[0].(*IfNode).Cond.Default)
+	assert.Equal(t, "x", tmpl.Nodes[0].(*IfNode).Then[0].(*TextNode).Text)
+}
@@ -0,0 +1,86 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/WhiCu/TCommit/internal/core/history"
+)
+
+// counterFile returns the file persisting the counters; replaced in tests.
+var counterFile = func() (string, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "counters.json"), nil
+}
+
+// counterMu serializes updates of the counter file within the process.
+var counterMu sync.Mutex
+
+// nextCounter increments the counter named by the first argument and returns its new value.
+// Every render calling it increments the counter, so a counter starts at 1.
+func nextCounter(args []string) (string, error) {
+	counterMu.Lock()
+	defer counterMu.Unlock()
+
+	path, counters, err := readCounters()
+	if err != nil {
+		return "", err
+	}
+
+	name := args[0]
+	counters[name]++
+
+	data, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(counters[name]), nil
+}
+
+// peekCounter returns the value nextCounter would return without saving it.
+func peekCounter(args []string) (string, error) {
+	counterMu.Lock()
+	defer counterMu.Unlock()
+
+	_, counters, err := readCounters()
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(counters[args[0]] + 1), nil
+}
+
+// readCounters returns the counter file and the counters it holds, none when it does not exist.
+func readCounters() (string, map[string]int, error) {
+	path, err := counterFile()
+	if err != nil {
+		return "", nil, err
+	}
+
+	counters := make(map[string]int)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return "", nil, err
+	default:
+		if err := json.Unmarshal(data, &counters); err != nil {
+			return "", nil, fmt.Errorf("invalid counter file %s: %w", path, err)
+		}
+	}
+	return path, counters, nil
+}
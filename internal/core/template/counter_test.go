@@ -0,0 +1,90 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useCounterFile stores the counters in a temporary file for the rest of the test
+func useCounterFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state", "counters.json")
+	orig := counterFile
+	counterFile = func() (string, error) { return path, nil }
+	t.Cleanup(func() { counterFile = orig })
+	return path
+}
+
+func TestCounterIncrements(t *testing.T) {
+	path := useCounterFile(t)
+
+	build, err := ParseString(`build #{{counter "build"}}`)
+	require.NoError(t, err)
+	release, err := ParseString(`release {{counter "release"}}`)
+	require.NoError(t, err)
+
+	empty := ReplacerFuncFromMap(nil)
+	for _, want := range []string{"build #1", "build #2", "build #3"} {
+		got, err := build.Execute(empty)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// Names are counted separately
+	got, err := release.Execute(empty)
+	require.NoError(t, err)
+	assert.Equal(t, "release 1", got)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"build": 3, "release": 1}`, string(data))
+}
+
+func TestCounterErrors(t *testing.T) {
+	path := useCounterFile(t)
+
+	_, err := ParseString(`{{counter}}`)
+	assert.ErrorIs(t, err, ErrTooFewArguments)
+
+	_, err = ParseString(`{{counter "a" "b"}}`)
+	assert.ErrorIs(t, err, ErrTooManyArguments)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	tmpl, err := ParseString(`{{counter "build"}}`)
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(nil))
+	assert.ErrorContains(t, err, "invalid counter file")
+}
+
+func TestCounterPeek(t *testing.T) {
+	path := useCounterFile(t)
+
+	tmpl, err := ParseString(`build #{{counter "build"}}`)
+	require.NoError(t, err)
+
+	// Peeking shows the next value without saving it, even before the first render
+	for _, r := range []Replacer{Peek(nil), PreviewReplacer(ReplacerFuncFromMap(nil)), MapReplacer(Peek(ReplacerFuncFromMap(nil)), strings.TrimSpace)} {
+		got, err := tmpl.Execute(r)
+		require.NoError(t, err)
+		assert.Equal(t, "build #1", got)
+	}
+	assert.NoFileExists(t, path)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "build #1", got)
+
+	got, err = tmpl.Execute(Peek(ReplacerFuncFromMap(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "build #2", got)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"build": 1}`, string(data))
+}
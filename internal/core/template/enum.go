@@ -0,0 +1,87 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// enumPrefix references a registered choice set: {{.type:@enum:committype}}
+const enumPrefix = defPrefix + "enum" + choiceSep
+
+// enums holds the named choice sets templates may reference.
+var (
+	enumsMu sync.RWMutex
+	enums   = map[string][]string{}
+)
+
+// RegisterEnum registers choices under name so templates can share them
+// as {{.key:@enum:name}}, replacing any set registered under the same name.
+// Templates resolve the reference when parsed, so register sets beforehand.
+func RegisterEnum(name string, choices []string) {
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	enums[name] = slices.Clone(choices)
+}
+
+// lookupEnum returns the choices registered under name
+func lookupEnum(name string) ([]string, error) {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	choices, ok := enums[name]
+	if !ok {
+		return nil, NewUnknownEnumError(name)
+	}
+	return choices, nil
+}
+
+// providerPrefix references a registered choice provider: {{.scope:@cmd:dirs}}
+const providerPrefix = defPrefix + "cmd" + choiceSep
+
+// ChoiceProvider computes choices when a template referencing it is parsed,
+// e.g. from the directories of a repository.
+type ChoiceProvider func() ([]string, error)
+
+// providers holds the choice providers templates may reference.
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ChoiceProvider{}
+)
+
+// RegisterChoiceProvider registers p under name so templates can take their
+// choices from it as {{.key:@cmd:name}}, replacing any provider registered
+// under the same name. It is called each time a template referencing it is parsed.
+func RegisterChoiceProvider(name string, p ChoiceProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = p
+}
+
+// provideChoices calls the provider registered under name
+func provideChoices(name string) ([]string, error) {
+	providersMu.RLock()
+	p, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, NewUnknownProviderError(name)
+	}
+	choices, err := p()
+	if err != nil {
+		return nil, NewProviderError(name, err)
+	}
+	return choices, nil
+}
+
+// referencedChoices resolves a choice naming an enum or a provider,
+// reporting false for any other choice
+func referencedChoices(choice string) ([]string, bool, error) {
+	if name, ok := strings.CutPrefix(choice, enumPrefix); ok {
+		choices, err := lookupEnum(strings.TrimSpace(name))
+		return choices, true, err
+	}
+	if name, ok := strings.CutPrefix(choice, providerPrefix); ok {
+		choices, err := provideChoices(strings.TrimSpace(name))
+		return choices, true, err
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,74 @@
+package template
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnum(t *testing.T) {
+	RegisterEnum("committype", []string{"feat", "fix", "docs"})
+	t.Cleanup(func() { delete(enums, "committype") })
+
+	tmpl, err := ParseString("{{.type:@enum:committype}}: {{.kind:@enum:committype|chore|@fix}}")
+	require.NoError(t, err)
+	vars := tmpl.Variables()
+	assert.Equal(t, []string{"feat", "fix", "docs"}, vars[0].Choices)
+	assert.False(t, vars[0].HasDef)
+	assert.Equal(t, []string{"feat", "fix", "docs", "chore"}, vars[1].Choices)
+	assert.Equal(t, "fix", vars[1].Default)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "docs"}))
+	require.NoError(t, err)
+	assert.Equal(t, "docs: fix", got)
+
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "chore"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestUnknownEnum(t *testing.T) {
+	_, err := ParseString("{{.type:@enum:missing}}")
+	require.ErrorIs(t, err, ErrUnknownEnum)
+	assert.ErrorIs(t, err, ErrInvalidTokenSyntax)
+
+	// A quoted default is taken literally
+	tmpl, err := ParseString(`{{.type:@"enum:missing"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "enum:missing", tmpl.Variables()[0].Default)
+}
+
+func TestChoiceProvider(t *testing.T) {
+	calls := 0
+	RegisterChoiceProvider("dirs", func() ([]string, error) {
+		calls++
+		return []string{"api", "cli", "docs"}, nil
+	})
+	RegisterChoiceProvider("broken", func() ([]string, error) {
+		return nil, errors.New("boom")
+	})
+	t.Cleanup(func() {
+		delete(providers, "dirs")
+		delete(providers, "broken")
+	})
+
+	tmpl, err := ParseString("feat({{.scope:@cmd:dirs|@core}}): x")
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"api", "cli", "docs", "core"}, tmpl.Variables()[0].Choices)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": "cli"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(cli): x", got)
+
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": "web"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	_, err = ParseString("{{.scope:@cmd:missing}}")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	_, err = ParseString("{{.scope:@cmd:broken}}")
+	require.ErrorIs(t, err, ErrProvider)
+	assert.ErrorContains(t, err, "boom")
+}
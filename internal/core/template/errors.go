@@ -1,12 +1,36 @@
 package template
 
-import "fmt"
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 // Common template errors
 var (
 	ErrInvalidTokenSyntax = fmt.Errorf("invalid token syntax")
 	ErrNoReplacement      = fmt.Errorf("no replacement for key")
 	ErrInvalidValue       = fmt.Errorf("invalid value for key")
+	ErrInvalidReplacement = fmt.Errorf("invalid replacement line")
+	ErrIncludeUnsupported = fmt.Errorf("include is only supported in template files")
+	ErrIncludeCycle       = fmt.Errorf("include cycle")
+	ErrInvalidPattern     = fmt.Errorf("invalid pattern")
+	ErrInvalidLength      = fmt.Errorf("invalid length constraint")
+	ErrUnknownFunction    = fmt.Errorf("unknown function")
+	ErrTooManyArguments   = fmt.Errorf("too many arguments")
+	ErrTooFewArguments    = fmt.Errorf("too few arguments")
+	ErrUnknownFilter      = fmt.Errorf("unknown filter")
+	ErrDefaultCycle       = fmt.Errorf("default cycle")
+	ErrUnknownEnum        = fmt.Errorf("unknown enum")
+	ErrUnknownProvider    = fmt.Errorf("unknown choice provider")
+	ErrProvider           = fmt.Errorf("choice provider failed")
+	ErrIsDirectory        = fmt.Errorf("expected a file, got a directory")
+	ErrNotBoolean         = fmt.Errorf("not a boolean")
+	ErrUnclosedIf         = fmt.Errorf("if without end")
+	ErrUnexpectedKeyword  = fmt.Errorf("unexpected keyword")
+	ErrNoFilesSource      = fmt.Errorf("no source of staged files for {{files}}")
 )
 
 // Error constructors
@@ -18,6 +42,126 @@ func NewInvalidValueError(val, key string, choices []string) error {
 	return fmt.Errorf("%w %q - %q; allowed: %v", ErrInvalidValue, key, val, choices)
 }
 
+func NewPatternMismatchError(val, key string, pattern *regexp.Regexp) error {
+	return fmt.Errorf("%w %q - %q; must match /%s/", ErrInvalidValue, key, val, pattern)
+}
+
+func NewInvalidPatternError(expr string, err error) error {
+	return fmt.Errorf("%w /%s/: %v", ErrInvalidPattern, expr, err)
+}
+
+func NewLengthMismatchError(val, key string, minLen, maxLen int) error {
+	return fmt.Errorf("%w %q - %q; length must be within %s", ErrInvalidValue, key, val, lengthRange(minLen, maxLen))
+}
+
+func NewInvalidLengthError(spec string) error {
+	return fmt.Errorf("%w %q (expected len=min..max)", ErrInvalidLength, spec)
+}
+
+// lengthRange formats length bounds as min..max, leaving unbounded sides empty
+func lengthRange(minLen, maxLen int) string {
+	lo, hi := "", ""
+	if minLen > 0 {
+		lo = strconv.Itoa(minLen)
+	}
+	if maxLen > 0 {
+		hi = strconv.Itoa(maxLen)
+	}
+	return lo + ".." + hi
+}
+
 func NewInvalidTokenSyntaxError(token string) error {
 	return fmt.Errorf("%w: %q", ErrInvalidTokenSyntax, token)
 }
+
+func NewInvalidReplacementLineError(line int, text string) error {
+	return fmt.Errorf("%w %d: %q (expected key=value)", ErrInvalidReplacement, line, text)
+}
+
+func NewIncludeUnsupportedError(name string) error {
+	return fmt.Errorf("%w: %q", ErrIncludeUnsupported, name)
+}
+
+func NewIncludeCycleError(chain []string) error {
+	return fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(chain, " -> "))
+}
+
+func NewDefaultCycleError(chain []string) error {
+	return fmt.Errorf("%w: %s", ErrDefaultCycle, strings.Join(chain, " -> "))
+}
+
+func NewUnknownFunctionError(name string) error {
+	return fmt.Errorf("%w: %w %q", ErrInvalidTokenSyntax, ErrUnknownFunction, name)
+}
+
+func NewTooManyArgumentsError(name string, maxArgs int) error {
+	return fmt.Errorf("%w: %w for %q (at most %d)", ErrInvalidTokenSyntax, ErrTooManyArguments, name, maxArgs)
+}
+
+func NewTooFewArgumentsError(name string, minArgs int) error {
+	return fmt.Errorf("%w: %w for %q (at least %d)", ErrInvalidTokenSyntax, ErrTooFewArguments, name, minArgs)
+}
+
+func NewUnknownFilterError(name string) error {
+	return fmt.Errorf("%w: %w %q", ErrInvalidTokenSyntax, ErrUnknownFilter, name)
+}
+
+func NewUnknownEnumError(name string) error {
+	return fmt.Errorf("%w: %w %q", ErrInvalidTokenSyntax, ErrUnknownEnum, name)
+}
+
+func NewUnknownProviderError(name string) error {
+	return fmt.Errorf("%w: %w %q", ErrInvalidTokenSyntax, ErrUnknownProvider, name)
+}
+
+func NewProviderError(name string, err error) error {
+	return fmt.Errorf("%w %q: %w", ErrProvider, name, err)
+}
+
+func NewNotBooleanError(val string) error {
+	return fmt.Errorf("%w: %q (expected true/false, yes/no, 1/0 or on/off)", ErrNotBoolean, val)
+}
+
+// NewConditionError reports the value of the if condition on key that cannot be used
+func NewConditionError(key string, err error) error {
+	return fmt.Errorf("condition %q: %w", key, err)
+}
+
+func NewUnexpectedKeywordError(keyword string) error {
+	return fmt.Errorf("%w %q", ErrUnexpectedKeyword, keyword)
+}
+
+// NewIsDirectoryError reports a template path naming a directory.
+// It is a *fs.PathError so callers treat it like other failures to read the file.
+func NewIsDirectoryError(path string) error {
+	return &fs.PathError{Op: "read", Path: path, Err: ErrIsDirectory}
+}
+
+// ParseError reports where in the input a token failed to parse.
+type ParseError struct {
+	// Offset is the byte offset of the token's opening marker
+	Offset int
+	// Line and Column locate the opening marker, starting at 1; Column counts bytes
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError locates offset within data, the input parsed so far.
+func newParseError(data string, offset int, err error) *ParseError {
+	before := data[:offset]
+	return &ParseError{
+		Offset: offset,
+		Line:   strings.Count(before, "\n") + 1,
+		Column: offset - strings.LastIndexByte(before, '\n'),
+		Err:    err,
+	}
+}
@@ -0,0 +1,45 @@
+package template
+
+import (
+	"strings"
+	"sync"
+)
+
+// filesSeparator joins the staged files when {{files}} is given no separator
+const filesSeparator = "\n"
+
+// stagedFiles lists the staged files for {{files}}; see SetStagedFilesSource.
+var (
+	stagedFilesMu sync.RWMutex
+	stagedFiles   func() ([]string, error)
+)
+
+// SetStagedFilesSource sets where {{files}} gets the staged files from,
+// e.g. git.GetStagedFiles with the runner of the repository.
+// Until a source is set {{files}} fails with ErrNoFilesSource.
+func SetStagedFilesSource(source func() ([]string, error)) {
+	stagedFilesMu.Lock()
+	defer stagedFilesMu.Unlock()
+	stagedFiles = source
+}
+
+// joinStagedFiles returns the staged files joined by the first argument or filesSeparator.
+func joinStagedFiles(args []string) (string, error) {
+	stagedFilesMu.RLock()
+	source := stagedFiles
+	stagedFilesMu.RUnlock()
+
+	if source == nil {
+		return "", ErrNoFilesSource
+	}
+	files, err := source()
+	if err != nil {
+		return "", err
+	}
+
+	sep := filesSeparator
+	if len(args) > 0 {
+		sep = args[0]
+	}
+	return strings.Join(files, sep), nil
+}
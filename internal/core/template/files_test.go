@@ -0,0 +1,61 @@
+package template
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStagedFiles makes {{files}} list files until the test finishes
+func fakeStagedFiles(t *testing.T, files []string, err error) {
+	t.Helper()
+	stagedFilesMu.RLock()
+	restore := stagedFiles
+	stagedFilesMu.RUnlock()
+	SetStagedFilesSource(func() ([]string, error) { return files, err })
+	t.Cleanup(func() { SetStagedFilesSource(restore) })
+}
+
+func TestFilesFunction(t *testing.T) {
+	fakeStagedFiles(t, []string{"go.mod", "main.go", "README.md"}, nil)
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: "Changed:\n{{files}}", want: "Changed:\ngo.mod\nmain.go\nREADME.md"},
+		{template: `Changed: {{files ", "}}`, want: "Changed: go.mod, main.go, README.md"},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParseString(tt.template)
+		require.NoError(t, err)
+		got, err := tmpl.Execute(ReplacerFuncFromMap(nil))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := ParseString(`{{files ", " "extra"}}`)
+	assert.ErrorIs(t, err, ErrTooManyArguments)
+}
+
+func TestFilesFunctionError(t *testing.T) {
+	boom := errors.New("not a git repository")
+	fakeStagedFiles(t, nil, boom)
+
+	tmpl, err := ParseString("{{files}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(nil))
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestFilesFunctionWithoutSource(t *testing.T) {
+	fakeStagedFiles(t, nil, nil)
+	SetStagedFilesSource(nil)
+
+	tmpl, err := ParseString("{{files}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(nil))
+	assert.ErrorIs(t, err, ErrNoFilesSource)
+}
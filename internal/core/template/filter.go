@@ -0,0 +1,49 @@
+package template
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filters is the closed set of value filters:
+//
+//	{{.desc|capitalize}}  upper-cases the first letter, leaving the rest untouched
+var filters = map[string]func(string) string{
+	"capitalize": capitalize,
+}
+
+// parseKey splits the key part of a variable token into the key and its filters.
+func parseKey(keyPart, token string) (string, []string, error) {
+	key, rest, hasFilters := strings.Cut(keyPart, filterSep)
+	key = strings.TrimSpace(key)
+	if !hasFilters {
+		return key, nil, nil
+	}
+
+	names := strings.Split(rest, filterSep)
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", nil, NewInvalidTokenSyntaxError(token)
+		}
+		if _, ok := filters[name]; !ok {
+			return "", nil, NewUnknownFilterError(name)
+		}
+		names[i] = name
+	}
+	return key, names, nil
+}
+
+// capitalize upper-cases the first rune of s.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	upper := unicode.ToUpper(r)
+	if upper == r {
+		return s
+	}
+	return string(upper) + s[size:]
+}
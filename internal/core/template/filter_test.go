@@ -0,0 +1,59 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapitalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "add login", want: "Add login"},
+		{in: "Add login", want: "Add login"},
+		{in: "éclair support", want: "Éclair support"},
+		{in: "ßtraße", want: "ßtraße"},
+		{in: "42 tests", want: "42 tests"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, capitalize(tt.in), tt.in)
+	}
+}
+
+func TestCapitalizeFilter(t *testing.T) {
+	tmpl, err := ParseString("{{.type}}: {{.desc|capitalize}}")
+	require.NoError(t, err)
+	assert.Equal(t, "desc", tmpl.Variables()[1].Key)
+	assert.Equal(t, []string{"capitalize"}, tmpl.Variables()[1].Filters)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "feat", "desc": "ñandú support"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat: Ñandú support", got)
+}
+
+func TestFilterWithConstraints(t *testing.T) {
+	// Choices validate the provided value, the filter shapes the output
+	tmpl, err := ParseString("{{.type | capitalize:feat|@fix}}")
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "feat"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Feat", got)
+
+	got, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{}))
+	require.NoError(t, err)
+	assert.Equal(t, "Fix", got)
+}
+
+func TestFilterParseErrors(t *testing.T) {
+	_, err := ParseString("{{.desc|shout}}")
+	assert.ErrorIs(t, err, ErrUnknownFilter)
+	assert.ErrorIs(t, err, ErrInvalidTokenSyntax)
+
+	_, err = ParseString("{{.desc|}}")
+	assert.ErrorIs(t, err, ErrInvalidTokenSyntax)
+}
@@ -0,0 +1,148 @@
+package template
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default layouts of the time functions
+const (
+	dateLayout = "2006-01-02"
+	nowLayout  = time.RFC3339
+)
+
+// builtin is a function callable from templates as {{name "arg" ...}}.
+type builtin struct {
+	minArgs int
+	maxArgs int
+	call    func(args []string) (string, error)
+	// peek, when set, replaces call for renders that are only displayed
+	peek func(args []string) (string, error)
+}
+
+// clock returns the current time; replaced in tests.
+var clock = time.Now
+
+// builtins is the closed set of template functions:
+//
+//	{{date}} or {{date "layout"}}  the current date, 2006-01-02 by default
+//	{{now}} or {{now "layout"}}    the current time, RFC 3339 by default
+//	{{counter "name"}}             the next value of a persistent counter, starting at 1
+//	{{files}} or {{files "sep"}}   the staged files, one per line by default
+//
+// Layouts use Go's reference time (Mon Jan 2 15:04:05 MST 2006).
+var builtins = map[string]builtin{
+	"date":    {maxArgs: 1, call: timeFunc(dateLayout)},
+	"now":     {maxArgs: 1, call: timeFunc(nowLayout)},
+	"counter": {minArgs: 1, maxArgs: 1, call: nextCounter, peek: peekCounter},
+	"files":   {maxArgs: 1, call: joinStagedFiles},
+}
+
+// timeFunc formats the current time with the given layout argument or def.
+func timeFunc(def string) func(args []string) (string, error) {
+	return func(args []string) (string, error) {
+		layout := def
+		if len(args) > 0 {
+			layout = args[0]
+		}
+		return clock().Format(layout), nil
+	}
+}
+
+// FuncNode calls one of the built-in template functions.
+type FuncNode struct {
+	Name string
+	Args []string
+}
+
+// WriteTo writes the result of the function call to w. Functions don't use
+// replacements, but a replacer wrapped with Peek keeps them from changing any state.
+func (f *FuncNode) WriteTo(w io.Writer, r Replacer) error {
+	fn, ok := builtins[f.Name]
+	if !ok {
+		return NewUnknownFunctionError(f.Name)
+	}
+
+	call := fn.call
+	if fn.peek != nil && isPeeking(r) {
+		call = fn.peek
+	}
+	val, err := call(f.Args)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, val)
+	return err
+}
+
+// String returns the token source of the call with its arguments quoted.
+func (f *FuncNode) String() string {
+	var b strings.Builder
+	b.WriteString(openMarker + f.Name)
+	for _, arg := range f.Args {
+		b.WriteString(" " + strconv.Quote(arg))
+	}
+	b.WriteString(closeMarker)
+	return b.String()
+}
+
+// parseFunc parses a function call token: a known function name followed by
+// quoted string arguments.
+func parseFunc(token string) (Node, error) {
+	t := strings.TrimSpace(token)
+	name, rest := t, ""
+	if idx := strings.IndexAny(t, whitespace); idx >= 0 {
+		name, rest = t[:idx], t[idx:]
+	}
+	if !isIdentifier(name) {
+		return nil, NewInvalidTokenSyntaxError(token)
+	}
+
+	fn, ok := builtins[name]
+	if !ok {
+		return nil, NewUnknownFunctionError(name)
+	}
+
+	args := make([]string, 0, fn.maxArgs)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, NewInvalidTokenSyntaxError(token)
+		}
+		arg, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, NewInvalidTokenSyntaxError(token)
+		}
+		args = append(args, arg)
+		rest = rest[len(quoted):]
+	}
+	if len(args) > fn.maxArgs {
+		return nil, NewTooManyArgumentsError(name, fn.maxArgs)
+	}
+	if len(args) < fn.minArgs {
+		return nil, NewTooFewArgumentsError(name, fn.minArgs)
+	}
+
+	return &FuncNode{
+		Name: name,
+		Args: args,
+	}, nil
+}
+
+// isIdentifier reports whether s is a non-empty run of letters, digits and
+// underscores starting with a letter.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
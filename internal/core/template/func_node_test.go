@@ -0,0 +1,69 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixClock makes the time functions return t for the rest of the test
+func fixClock(t *testing.T, at time.Time) {
+	t.Helper()
+	orig := clock
+	clock = func() time.Time { return at }
+	t.Cleanup(func() { clock = orig })
+}
+
+func TestTimeFunctions(t *testing.T) {
+	fixClock(t, time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"Date with layout", `Released {{date "2006-01-02"}}`, "Released 2024-03-05"},
+		{"Date default layout", `{{ date }}`, "2024-03-05"},
+		{"Date custom layout", `{{date "Jan 2, 2006"}}`, "Mar 5, 2024"},
+		{"Now default layout", `{{now}}`, "2024-03-05T14:30:00Z"},
+		{"Now with layout", `{{now "15:04"}}`, "14:30"},
+		{"Mixed with variables", `{{.type}} {{- date " (2006)" -}}`, "feat (2024)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString(tc.template)
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "feat"}))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFunctionParseErrors(t *testing.T) {
+	_, err := ParseString(`{{time "2006"}}`)
+	require.ErrorIs(t, err, ErrUnknownFunction)
+	require.ErrorIs(t, err, ErrInvalidTokenSyntax)
+
+	_, err = ParseString(`{{date "2006" "01"}}`)
+	require.ErrorIs(t, err, ErrTooManyArguments)
+
+	for _, token := range []string{`{{date 2006}}`, `{{date "2006}}`, `{{"date"}}`, `{{ }}`} {
+		_, err = ParseString(token)
+		assert.ErrorIs(t, err, ErrInvalidTokenSyntax, token)
+	}
+}
+
+func TestFunctionTabSeparatedArgs(t *testing.T) {
+	fixClock(t, time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC))
+
+	tmpl, err := ParseString("{{date\t\"2006\"}}")
+	require.NoError(t, err)
+	got, err := tmpl.Execute(ReplacerFuncFromMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "2024", got)
+}
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// includeFunc resolves the name of an include token to the nodes it expands to.
+type includeFunc func(name string) ([]Node, error)
+
+// ParseFile reads and parses the template file at path.
+// Syntax errors are prefixed with the path of the file they occur in.
+// Unlike Parse it supports {{include "file"}} tokens, which splice another
+// template in at parse time. Included paths are resolved relative to the
+// directory of the including file, and include cycles are reported as errors.
+func ParseFile(path string) (*Template, error) {
+	nodes, err := parseFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template{
+		Nodes: nodes,
+	}, nil
+}
+
+// parseFile parses the file at path, with chain holding the files
+// currently being included to detect cycles.
+func parseFile(path string, chain []string) ([]Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range chain {
+		if p == abs {
+			return nil, NewIncludeCycleError(append(chain, abs))
+		}
+	}
+	chain = append(chain, abs)
+
+	// Reading a directory fails with a less helpful error on some systems
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		return nil, NewIsDirectoryError(path)
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(abs)
+	nodes, err := parseNodes(string(data), func(name string) ([]Node, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return parseFile(name, chain)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return nodes, nil
+}
+
+// parseInclude reports whether token is an include and returns the included name.
+// The error is set when the token is an include with malformed syntax.
+func parseInclude(token string) (name string, ok bool, err error) {
+	t := strings.TrimSpace(token)
+	rest, found := strings.CutPrefix(t, includeKeyword)
+	if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false, nil
+	}
+
+	name, err = strconv.Unquote(strings.TrimSpace(rest))
+	if err != nil || name == "" {
+		return "", true, NewInvalidTokenSyntaxError(token)
+	}
+	return name, true, nil
+}
@@ -0,0 +1,117 @@
+package template
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates files relative to a fresh temp dir and returns the dir
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestParseFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"valid.tmpl":   "Hello {{.name:@World}}!",
+		"invalid.tmpl": "Hello {{name}}!",
+	})
+
+	tmpl, err := ParseFile(filepath.Join(dir, "valid.tmpl"))
+	require.NoError(t, err)
+	got, err := tmpl.Execute(ReplacerFuncFromMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World!", got)
+
+	missing := filepath.Join(dir, "missing.tmpl")
+	_, err = ParseFile(missing)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), missing)
+
+	invalid := filepath.Join(dir, "invalid.tmpl")
+	_, err = ParseFile(invalid)
+	require.ErrorIs(t, err, ErrInvalidTokenSyntax)
+	assert.Contains(t, err.Error(), invalid)
+}
+
+func TestParseFileInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"header.tmpl": "{{.type}}: ",
+		"commit.tmpl": `{{include "header.tmpl"}}{{.desc}}`,
+	})
+
+	tmpl, err := ParseFile(filepath.Join(dir, "commit.tmpl"))
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "feat", "desc": "add login"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login", got)
+}
+
+func TestParseFileNestedInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"commit.tmpl":        `{{ include "parts/body.tmpl" }}`,
+		"parts/body.tmpl":    `[{{include "footer.tmpl"}}]`,
+		"parts/footer.tmpl":  "Refs: {{.ticket}}",
+		"unrelated/any.tmpl": "never read",
+	})
+
+	tmpl, err := ParseFile(filepath.Join(dir, "commit.tmpl"))
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"ticket": "ABC-1"}))
+	require.NoError(t, err)
+	assert.Equal(t, "[Refs: ABC-1]", got)
+}
+
+func TestParseFileIncludeErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.tmpl":       `A {{include "b.tmpl"}}`,
+		"b.tmpl":       `B {{include "a.tmpl"}}`,
+		"self.tmpl":    `{{include "self.tmpl"}}`,
+		"missing.tmpl": `{{include "nope.tmpl"}}`,
+		"bad.tmpl":     `{{include header.tmpl}}`,
+	})
+
+	_, err := ParseFile(filepath.Join(dir, "a.tmpl"))
+	require.ErrorIs(t, err, ErrIncludeCycle)
+
+	_, err = ParseFile(filepath.Join(dir, "self.tmpl"))
+	require.ErrorIs(t, err, ErrIncludeCycle)
+
+	_, err = ParseFile(filepath.Join(dir, "missing.tmpl"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = ParseFile(filepath.Join(dir, "bad.tmpl"))
+	require.ErrorIs(t, err, ErrInvalidTokenSyntax)
+}
+
+func TestParseStringRejectsInclude(t *testing.T) {
+	_, err := ParseString(`{{include "header.tmpl"}}`)
+	require.ErrorIs(t, err, ErrIncludeUnsupported)
+
+	// A variable that merely starts with the keyword is still a variable
+	_, err = ParseString(`{{.includes}}`)
+	require.NoError(t, err)
+}
+
+func TestParseFileDirectory(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ParseFile(dir)
+	require.ErrorIs(t, err, ErrIsDirectory)
+
+	var pathErr *fs.PathError
+	require.ErrorAs(t, err, &pathErr)
+	assert.Equal(t, dir, pathErr.Path)
+}
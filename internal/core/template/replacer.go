@@ -1,9 +1,61 @@
 package template
 
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
 type Replacer interface {
 	Get(key string) (string, bool)
 }
 
+// StrictReplacer is an optional extension of Replacer.
+// When Strict reports true, keys the replacer does not provide are errors
+// even if the template declares a default for them.
+type StrictReplacer interface {
+	Replacer
+	Strict() bool
+}
+
+// isStrict reports whether r forbids falling back to template defaults.
+func isStrict(r Replacer) bool {
+	s, ok := r.(StrictReplacer)
+	return ok && s.Strict()
+}
+
+// DefaultOnEmptyReplacer is an optional extension of Replacer.
+// When UseDefaultOnEmpty reports true, empty values of variables declaring
+// a default render the default, as if the replacer did not provide them.
+type DefaultOnEmptyReplacer interface {
+	Replacer
+	UseDefaultOnEmpty() bool
+}
+
+// usesDefaultOnEmpty reports whether empty values of r fall back to template defaults.
+func usesDefaultOnEmpty(r Replacer) bool {
+	d, ok := r.(DefaultOnEmptyReplacer)
+	return ok && d.UseDefaultOnEmpty()
+}
+
+// peekingReplacer is implemented by replacers rendering for display only, such
+// as previews: functions with side effects, like {{counter}}, then show their
+// next value without advancing it.
+type peekingReplacer interface {
+	Replacer
+	peek() bool
+}
+
+// isPeeking reports whether r renders for display only.
+func isPeeking(r Replacer) bool {
+	p, ok := r.(peekingReplacer)
+	return ok && p.peek()
+}
+
 type ReplacerFunc func(key string) (string, bool)
 
 func (f ReplacerFunc) Get(key string) (string, bool) {
@@ -16,3 +68,446 @@ func ReplacerFuncFromMap(m map[string]string) ReplacerFunc {
 		return v, ok
 	}
 }
+
+// MergeMaps merges maps into a new map, values of later maps winning.
+// Nil maps are skipped; the result is never nil.
+//
+// Example:
+//
+//	MergeMaps(defaults, fromFile, fromFlags)
+func MergeMaps(maps ...map[string]string) map[string]string {
+	size := 0
+	for _, m := range maps {
+		size = max(size, len(m))
+	}
+
+	merged := make(map[string]string, size)
+	for _, m := range maps {
+		for key, value := range m {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// expensiveReplacer is implemented by replacers whose lookups are costly
+// enough to be memoized for the duration of one execution.
+type expensiveReplacer interface {
+	Replacer
+	expensive()
+}
+
+// memoReplacer remembers the lookups of a Replacer.
+type memoReplacer struct {
+	r     Replacer
+	cache map[string]memoEntry
+}
+
+type memoEntry struct {
+	val   string
+	found bool
+}
+
+// memoize wraps r so each key is looked up at most once if r is expensive.
+// Cheap replacers are returned as is to keep execution allocation-free.
+func memoize(r Replacer) Replacer {
+	if _, ok := r.(expensiveReplacer); !ok {
+		return r
+	}
+	return &memoReplacer{r: r, cache: make(map[string]memoEntry)}
+}
+
+func (m *memoReplacer) Get(key string) (string, bool) {
+	if e, ok := m.cache[key]; ok {
+		return e.val, e.found
+	}
+	val, found := m.r.Get(key)
+	m.cache[key] = memoEntry{val: val, found: found}
+	return val, found
+}
+
+func (m *memoReplacer) Strict() bool {
+	return isStrict(m.r)
+}
+
+func (m *memoReplacer) UseDefaultOnEmpty() bool {
+	return usesDefaultOnEmpty(m.r)
+}
+
+func (m *memoReplacer) peek() bool {
+	return isPeeking(m.r)
+}
+
+// lazyMap is a Replacer computing its values on demand.
+type lazyMap map[string]func() (string, bool)
+
+func (m lazyMap) Get(key string) (string, bool) {
+	provider, ok := m[key]
+	if !ok {
+		return "", false
+	}
+	return provider()
+}
+
+func (lazyMap) expensive() {}
+
+// LazyReplacer returns a Replacer whose values are computed by providers.
+// A provider is only called when the template looks up its key, which suits
+// values that are expensive to obtain such as git queries.
+// Within one execution each provider is called at most once.
+func LazyReplacer(m map[string]func() (string, bool)) Replacer {
+	return lazyMap(m)
+}
+
+// strictMap is a map-backed StrictReplacer.
+type strictMap map[string]string
+
+func (m strictMap) Get(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (strictMap) Strict() bool {
+	return true
+}
+
+// StrictMapReplacer returns a Replacer serving values from m that makes
+// every key missing from m an error, ignoring template defaults.
+// It is meant for catching typos in templates, e.g. in CI.
+func StrictMapReplacer(m map[string]string) Replacer {
+	return strictMap(m)
+}
+
+// nestedMap is a Replacer resolving dotted keys against nested maps.
+type nestedMap map[string]any
+
+func (m nestedMap) Get(key string) (string, bool) {
+	v, ok := lookupNested(m, key)
+	if !ok {
+		return "", false
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case map[string]any, map[string]string:
+		// A namespace is not a value
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// lookupNested finds key in m, preferring a literal match at every level
+// before descending into a nested map named by a dotted prefix of the key.
+func lookupNested(m map[string]any, key string) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for i := strings.IndexByte(key, '.'); i >= 0; {
+		switch sub := m[key[:i]].(type) {
+		case map[string]any:
+			if v, ok := lookupNested(sub, key[i+1:]); ok {
+				return v, true
+			}
+		case map[string]string:
+			if v, ok := sub[key[i+1:]]; ok {
+				return v, true
+			}
+		}
+		next := strings.IndexByte(key[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, false
+}
+
+// ReplacerFromNestedMap returns a Replacer serving namespaced keys such as
+// {{.git.branch}} from nested maps, e.g. {"git": {"branch": "main"}}.
+// A flat entry with the literal dotted key is used as well and takes precedence.
+// Non-string values are formatted with fmt.Sprint.
+func ReplacerFromNestedMap(m map[string]any) Replacer {
+	return nestedMap(m)
+}
+
+// mappedReplacer applies fn to every value found by r.
+type mappedReplacer struct {
+	r  Replacer
+	fn func(string) string
+}
+
+func (m mappedReplacer) Get(key string) (string, bool) {
+	val, ok := m.r.Get(key)
+	if !ok {
+		return "", false
+	}
+	return m.fn(val), true
+}
+
+func (m mappedReplacer) Strict() bool {
+	return isStrict(m.r)
+}
+
+func (m mappedReplacer) UseDefaultOnEmpty() bool {
+	return usesDefaultOnEmpty(m.r)
+}
+
+func (m mappedReplacer) peek() bool {
+	return isPeeking(m.r)
+}
+
+// expensiveMappedReplacer keeps memoizing an expensive wrapped replacer.
+type expensiveMappedReplacer struct {
+	mappedReplacer
+}
+
+func (expensiveMappedReplacer) expensive() {}
+
+// MapReplacer returns a Replacer applying fn to every value r provides,
+// e.g. strings.TrimSpace to trim all values. Template defaults are not
+// transformed. Strictness and memoization of r are preserved.
+func MapReplacer(r Replacer, fn func(string) string) Replacer {
+	m := mappedReplacer{r: r, fn: fn}
+	if _, ok := r.(expensiveReplacer); ok {
+		return expensiveMappedReplacer{m}
+	}
+	return m
+}
+
+// EscapeMarkers breaks up the template markers in s by inserting a backslash
+// between repeated braces, so {{ becomes {\{ and }}} becomes }\}\}.
+// The result holds no marker, which keeps values from passing for template
+// tokens, e.g. with MapReplacer(r, EscapeMarkers).
+func EscapeMarkers(s string) string {
+	if !strings.Contains(s, openMarker) && !strings.Contains(s, closeMarker) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	for i := 0; i < len(s); i++ {
+		if i > 0 && (s[i] == '{' || s[i] == '}') && s[i] == s[i-1] {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// trackingReplacer records the keys found by lookups of r.
+type trackingReplacer struct {
+	r    Replacer
+	mu   *sync.Mutex
+	used map[string]bool
+}
+
+func (t trackingReplacer) Get(key string) (string, bool) {
+	val, ok := t.r.Get(key)
+	if ok {
+		t.mu.Lock()
+		t.used[key] = true
+		t.mu.Unlock()
+	}
+	return val, ok
+}
+
+func (t trackingReplacer) Strict() bool {
+	return isStrict(t.r)
+}
+
+func (t trackingReplacer) UseDefaultOnEmpty() bool {
+	return usesDefaultOnEmpty(t.r)
+}
+
+func (t trackingReplacer) peek() bool {
+	return isPeeking(t.r)
+}
+
+// expensiveTrackingReplacer keeps memoizing an expensive wrapped replacer.
+type expensiveTrackingReplacer struct {
+	trackingReplacer
+}
+
+func (expensiveTrackingReplacer) expensive() {}
+
+// TrackingReplacer wraps r to record which keys the template uses, e.g. to find
+// stale entries in a large set of replacements. After executing, report returns
+// the keys r provided values for and those of provided that were never used, both sorted.
+// Strictness and memoization of r are preserved.
+func TrackingReplacer(r Replacer, provided ...string) (tracked Replacer, report func() (used, unused []string)) {
+	t := trackingReplacer{r: r, mu: new(sync.Mutex), used: make(map[string]bool)}
+	tracked = t
+	if _, ok := r.(expensiveReplacer); ok {
+		tracked = expensiveTrackingReplacer{t}
+	}
+
+	report = func() (used, unused []string) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for key := range t.used {
+			used = append(used, key)
+		}
+		for _, key := range provided {
+			if !t.used[key] && !slices.Contains(unused, key) {
+				unused = append(unused, key)
+			}
+		}
+		slices.Sort(used)
+		slices.Sort(unused)
+		return used, unused
+	}
+	return tracked, report
+}
+
+// defaultOnEmpty is a Replacer treating empty values as missing where a default exists.
+type defaultOnEmpty struct {
+	Replacer
+}
+
+func (d defaultOnEmpty) Strict() bool {
+	return isStrict(d.Replacer)
+}
+
+func (d defaultOnEmpty) peek() bool {
+	return isPeeking(d.Replacer)
+}
+
+func (defaultOnEmpty) UseDefaultOnEmpty() bool {
+	return true
+}
+
+// expensiveDefaultOnEmpty keeps memoizing an expensive wrapped replacer.
+type expensiveDefaultOnEmpty struct {
+	defaultOnEmpty
+}
+
+func (expensiveDefaultOnEmpty) expensive() {}
+
+// UseDefaultOnEmpty wraps r so that an empty value, such as name= given on the
+// command line, renders the template default of its variable instead of nothing.
+// Variables without a default still render the empty value.
+// Strictness and memoization of r are preserved.
+func UseDefaultOnEmpty(r Replacer) Replacer {
+	d := defaultOnEmpty{r}
+	if _, ok := r.(expensiveReplacer); ok {
+		return expensiveDefaultOnEmpty{d}
+	}
+	return d
+}
+
+// previewReplacer renders keys without a value or default as visible markers.
+// It renders for display only, see Peek.
+type previewReplacer struct {
+	Replacer
+}
+
+func (previewReplacer) peek() bool {
+	return true
+}
+
+func (p previewReplacer) UseDefaultOnEmpty() bool {
+	return usesDefaultOnEmpty(p.Replacer)
+}
+
+// placeholder returns the marker written for a key nothing provides
+func (previewReplacer) placeholder(key string) string {
+	return "<" + key + "?>"
+}
+
+// placeholderReplacer is implemented by replacers that render missing keys
+// as markers instead of failing.
+type placeholderReplacer interface {
+	Replacer
+	placeholder(key string) string
+}
+
+// PreviewReplacer wraps r for previewing a template: values come from r,
+// template defaults are used where r has none and variables with neither
+// render as a <key?> marker instead of failing the execution.
+func PreviewReplacer(r Replacer) Replacer {
+	return previewReplacer{r}
+}
+
+// peekReplacer renders for display only, see Peek.
+type peekReplacer struct {
+	Replacer
+}
+
+func (p peekReplacer) Get(key string) (string, bool) {
+	if p.Replacer == nil {
+		return "", false
+	}
+	return p.Replacer.Get(key)
+}
+
+func (p peekReplacer) Strict() bool {
+	return isStrict(p.Replacer)
+}
+
+func (p peekReplacer) UseDefaultOnEmpty() bool {
+	return usesDefaultOnEmpty(p.Replacer)
+}
+
+func (peekReplacer) peek() bool {
+	return true
+}
+
+// expensivePeekReplacer keeps memoizing an expensive wrapped replacer.
+type expensivePeekReplacer struct {
+	peekReplacer
+}
+
+func (expensivePeekReplacer) expensive() {}
+
+// Peek wraps r for renders that are only displayed, e.g. copied or shown
+// while editing, ahead of the render committed: functions with side effects
+// then leave their state alone, so {{counter}} shows its next value without
+// advancing it. A nil r provides no values. PreviewReplacer peeks as well.
+// Strictness and memoization of r are preserved.
+func Peek(r Replacer) Replacer {
+	p := peekReplacer{r}
+	if _, ok := r.(expensiveReplacer); ok {
+		return expensivePeekReplacer{p}
+	}
+	return p
+}
+
+// ReplacerFromFile loads replacements from a file of key=value lines.
+// Blank lines and lines starting with # are ignored, keys and values are trimmed
+// and values may themselves contain '='.
+func ReplacerFromFile(path string) (Replacer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	m, err := parseKeyValues(file)
+	if err != nil {
+		return nil, err
+	}
+	return ReplacerFuncFromMap(m), nil
+}
+
+// parseKeyValues reads key=value lines from r into a map.
+func parseKeyValues(r io.Reader) (map[string]string, error) {
+	m := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, NewInvalidReplacementLineError(line, text)
+		}
+		m[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
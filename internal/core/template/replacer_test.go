@@ -0,0 +1,388 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTemp writes content into a file inside a fresh temp dir and returns its path
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestReplacerFromFile(t *testing.T) {
+	path := writeTemp(t, "values.env", `
+# commit type
+type = feat
+
+scope=auth
+  # indented comment
+url=https://example.com/?a=b
+empty=
+`)
+
+	r, err := ReplacerFromFile(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"type", "feat", true},
+		{"scope", "auth", true},
+		{"url", "https://example.com/?a=b", true},
+		{"empty", "", true},
+		{"# commit type", "", false},
+		{"missing", "", false},
+	}
+	for _, tc := range tests {
+		got, found := r.Get(tc.key)
+		assert.Equal(t, tc.found, found, tc.key)
+		assert.Equal(t, tc.want, got, tc.key)
+	}
+}
+
+func TestReplacerFromFileMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    string
+	}{
+		{"Missing separator", "type=feat\n\nscope\n", "line 3"},
+		{"Empty key", "# header\n = value\n", "line 2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReplacerFromFile(writeTemp(t, "values.env", tc.content))
+			require.ErrorIs(t, err, ErrInvalidReplacement)
+			assert.Contains(t, err.Error(), tc.line)
+		})
+	}
+}
+
+func TestReplacerFromFileMissing(t *testing.T) {
+	_, err := ReplacerFromFile(filepath.Join(t.TempDir(), "missing.env"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestStrictMapReplacer(t *testing.T) {
+	tmpl, err := ParseString("{{.type:@feat}}({{.scope:@core}}): {{.desc}}")
+	require.NoError(t, err)
+
+	values := map[string]string{"type": "fix", "desc": "typo"}
+
+	got, err := tmpl.Execute(ReplacerFuncFromMap(values))
+	require.NoError(t, err)
+	assert.Equal(t, "fix(core): typo", got)
+
+	_, err = tmpl.Execute(StrictMapReplacer(values))
+	require.ErrorIs(t, err, ErrNoReplacement)
+	assert.Contains(t, err.Error(), `"scope"`)
+
+	values["scope"] = "cli"
+	got, err = tmpl.Execute(StrictMapReplacer(values))
+	require.NoError(t, err)
+	assert.Equal(t, "fix(cli): typo", got)
+}
+
+func TestLazyReplacer(t *testing.T) {
+	calls := map[string]int{}
+	provider := func(key, value string, found bool) func() (string, bool) {
+		return func() (string, bool) {
+			calls[key]++
+			return value, found
+		}
+	}
+
+	r := LazyReplacer(map[string]func() (string, bool){
+		"branch": provider("branch", "main", true),
+		"author": provider("author", "Jane", true),
+		"scope":  provider("scope", "", false),
+	})
+
+	tmpl, err := ParseString("{{.branch}}: {{.scope:@core}}")
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(r)
+	require.NoError(t, err)
+	assert.Equal(t, "main: core", got)
+
+	assert.Equal(t, map[string]int{"branch": 1, "scope": 1}, calls)
+}
+
+func TestLazyReplacerMemoizedPerExecution(t *testing.T) {
+	calls := 0
+	r := LazyReplacer(map[string]func() (string, bool){
+		"branch": func() (string, bool) {
+			calls++
+			return "main", true
+		},
+	})
+
+	tmpl, err := ParseString("{{.branch}} {{.branch:@dev}} {{.branch}}")
+	require.NoError(t, err)
+
+	got, err := tmpl.Execute(r)
+	require.NoError(t, err)
+	assert.Equal(t, "main main main", got)
+	assert.Equal(t, 1, calls)
+
+	// A new execution resolves again
+	_, err = tmpl.ExecuteBytes(r)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestParseDottedKey(t *testing.T) {
+	tmpl, err := ParseString("{{.git.branch}} {{.ci.job:@build}}")
+	require.NoError(t, err)
+
+	vars := tmpl.Variables()
+	require.Len(t, vars, 2)
+	assert.Equal(t, "git.branch", vars[0].Key)
+	assert.Equal(t, "ci.job", vars[1].Key)
+}
+
+func TestReplacerFromNestedMap(t *testing.T) {
+	r := ReplacerFromNestedMap(map[string]any{
+		"git": map[string]any{
+			"branch": "main",
+			"remote": map[string]any{"name": "origin"},
+		},
+		"ci":        map[string]string{"job": "test"},
+		"ci.job":    "flat wins",
+		"build.num": 42,
+		"user.name": "Jane",
+		"user":      map[string]any{"email": "jane@example.com"},
+	})
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{key: "git.branch", want: "main", found: true},
+		{key: "git.remote.name", want: "origin", found: true},
+		{key: "ci.job", want: "flat wins", found: true},
+		{key: "build.num", want: "42", found: true},
+		{key: "user.name", want: "Jane", found: true},
+		{key: "user.email", want: "jane@example.com", found: true},
+		{key: "git", found: false},
+		{key: "git.remote", found: false},
+		{key: "git.tag", found: false},
+		{key: "git.branch.name", found: false},
+		{key: "missing.path", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, found := r.Get(tt.key)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteNestedMap(t *testing.T) {
+	tmpl, err := ParseString("{{.git.branch}} by {{.user.name}}{{.ci.job:@}}")
+	require.NoError(t, err)
+
+	out, err := tmpl.Execute(ReplacerFromNestedMap(map[string]any{
+		"git":  map[string]any{"branch": "main"},
+		"user": map[string]any{"name": "Jane"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "main by Jane", out)
+
+	_, err = tmpl.Execute(ReplacerFromNestedMap(map[string]any{
+		"git": map[string]any{},
+	}))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+}
+
+func TestMapReplacer(t *testing.T) {
+	values := map[string]string{"type": "  feat ", "desc": "add login\nand logout\r\n"}
+	tmpl, err := ParseString("{{.type}}: {{.desc}}{{.scope:@ core }}")
+	require.NoError(t, err)
+
+	trimmed, err := tmpl.Execute(MapReplacer(ReplacerFuncFromMap(values), strings.TrimSpace))
+	require.NoError(t, err)
+	// Defaults are left as written
+	assert.Equal(t, "feat: add login\nand logout core", trimmed)
+
+	oneLine := MapReplacer(ReplacerFuncFromMap(values), func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	})
+	got, err := tmpl.Execute(oneLine)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login and logout core", got)
+
+	_, found := oneLine.Get("missing")
+	assert.False(t, found)
+}
+
+func TestMapReplacerPreservesStrictAndMemo(t *testing.T) {
+	tmpl, err := ParseString("{{.a}} {{.a}} {{.b:@x}}")
+	require.NoError(t, err)
+
+	_, err = tmpl.Execute(MapReplacer(StrictMapReplacer(map[string]string{"a": "1"}), strings.ToUpper))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+
+	calls := 0
+	lazy := LazyReplacer(map[string]func() (string, bool){
+		"a": func() (string, bool) { calls++; return "v", true },
+	})
+	got, err := tmpl.Execute(MapReplacer(lazy, strings.ToUpper))
+	require.NoError(t, err)
+	assert.Equal(t, "V V x", got)
+	assert.Equal(t, 1, calls)
+}
+
+func TestUseDefaultOnEmpty(t *testing.T) {
+	tmpl, err := ParseString("Hello {{.name:@World}}{{.suffix}}")
+	require.NoError(t, err)
+	values := map[string]string{"name": "", "suffix": ""}
+
+	// By default an empty value is still a value
+	got, err := tmpl.Execute(ReplacerFuncFromMap(values))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello ", got)
+
+	// Variables without a default keep rendering empty
+	got, err = tmpl.Execute(UseDefaultOnEmpty(ReplacerFuncFromMap(values)))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World", got)
+
+	got, err = tmpl.Execute(UseDefaultOnEmpty(ReplacerFuncFromMap(map[string]string{"name": "Go", "suffix": "!"})))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Go!", got)
+}
+
+func TestUseDefaultOnEmptyThroughWrappers(t *testing.T) {
+	tmpl, err := ParseString("{{.name:@World}}")
+	require.NoError(t, err)
+
+	calls := 0
+	lazy := LazyReplacer(map[string]func() (string, bool){
+		"name": func() (string, bool) { calls++; return "", true },
+	})
+	got, err := tmpl.Execute(MapReplacer(UseDefaultOnEmpty(lazy), strings.TrimSpace))
+	require.NoError(t, err)
+	assert.Equal(t, "World", got)
+	assert.Equal(t, 1, calls)
+
+	got, err = tmpl.Execute(PreviewReplacer(UseDefaultOnEmpty(ReplacerFuncFromMap(map[string]string{"name": ""}))))
+	require.NoError(t, err)
+	assert.Equal(t, "World", got)
+
+	// Strict replacers allow no defaults, so an empty value becomes an error
+	_, err = tmpl.Execute(UseDefaultOnEmpty(StrictMapReplacer(map[string]string{"name": ""})))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+}
+
+func TestEscapeMarkers(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain {text}", want: "plain {text}"},
+		{in: "a {{b}} c", want: `a {\{b}\} c`},
+		{in: "}}}", want: `}\}\}`},
+		{in: "{{{", want: `{\{\{`},
+		{in: "{}{}", want: "{}{}"},
+	}
+	for _, tt := range tests {
+		got := EscapeMarkers(tt.in)
+		assert.Equal(t, tt.want, got)
+		assert.NotContains(t, got, openMarker)
+		assert.NotContains(t, got, closeMarker)
+	}
+
+	tmpl, err := ParseString("feat: {{.desc}}")
+	require.NoError(t, err)
+	got, err := tmpl.Execute(MapReplacer(ReplacerFuncFromMap(map[string]string{"desc": "use {{.x}}"}), EscapeMarkers))
+	require.NoError(t, err)
+	assert.Equal(t, `feat: use {\{.x}\}`, got)
+}
+
+func TestPreviewReplacer(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat|@fix}}({{.scope?}}): {{.desc:/^[a-z]/}} {{.issue}}")
+	require.NoError(t, err)
+
+	// Defaults are used and missing required variables become markers, skipping validation
+	got, err := tmpl.Execute(PreviewReplacer(ReplacerFuncFromMap(map[string]string{"issue": "#1"})))
+	require.NoError(t, err)
+	assert.Equal(t, "fix(): <desc?> #1", got)
+
+	// Provided values are still validated
+	_, err = tmpl.Execute(PreviewReplacer(ReplacerFuncFromMap(map[string]string{"type": "docs"})))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestTrackingReplacer(t *testing.T) {
+	tmpl, err := ParseString("{{.type:@feat}}({{.scope:@core}}): {{.desc}} {{.desc}}")
+	require.NoError(t, err)
+
+	values := map[string]string{"desc": "add login", "scope": "auth", "ticket": "ABC-1", "author": "Jane"}
+	provided := make([]string, 0, len(values))
+	for key := range values {
+		provided = append(provided, key)
+	}
+
+	r, report := TrackingReplacer(ReplacerFuncFromMap(values), provided...)
+	got, err := tmpl.Execute(r)
+	require.NoError(t, err)
+	assert.Equal(t, "feat(auth): add login add login", got)
+
+	used, unused := report()
+	// type fell back to its default, so it was not provided
+	assert.Equal(t, []string{"desc", "scope"}, used)
+	assert.Equal(t, []string{"author", "ticket"}, unused)
+}
+
+func TestTrackingReplacerPreservesStrictAndMemo(t *testing.T) {
+	r, _ := TrackingReplacer(StrictMapReplacer(nil))
+	assert.True(t, isStrict(r))
+
+	calls := 0
+	lazy := LazyReplacer(map[string]func() (string, bool){
+		"x": func() (string, bool) { calls++; return "1", true },
+	})
+	r, report := TrackingReplacer(lazy)
+	tmpl, err := ParseString("{{.x}}{{.x}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(r)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	used, unused := report()
+	assert.Equal(t, []string{"x"}, used)
+	assert.Empty(t, unused)
+}
+
+func TestMergeMaps(t *testing.T) {
+	defaults := map[string]string{"type": "chore", "scope": "core"}
+	file := map[string]string{"type": "feat", "desc": "from file"}
+	flags := map[string]string{"desc": "from flags"}
+
+	assert.Equal(t, map[string]string{"type": "feat", "scope": "core", "desc": "from flags"},
+		MergeMaps(defaults, file, flags))
+	// The inputs are left untouched
+	assert.Equal(t, map[string]string{"type": "chore", "scope": "core"}, defaults)
+
+	assert.Equal(t, map[string]string{"type": "chore", "scope": "core"}, MergeMaps(nil, defaults, map[string]string{}, nil))
+
+	merged := MergeMaps()
+	require.NotNil(t, merged)
+	assert.Empty(t, merged)
+	assert.NotNil(t, MergeMaps(nil, nil))
+}
@@ -0,0 +1,187 @@
+package template
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// streamBufferSize is the read buffer size used by ParseStream.
+const streamBufferSize = 4096
+
+// ParseStream parses a template incrementally from r without buffering
+// the whole input, which suits large templates read from a pipe.
+// It accepts the same syntax as Parse and likewise does not support includes.
+func ParseStream(r io.Reader) (*Template, error) {
+	return parseStream(bufio.NewReaderSize(r, streamBufferSize))
+}
+
+// parseStream parses the template read from br, emitting nodes as markers are found.
+func parseStream(br *bufio.Reader) (*Template, error) {
+	p := blockParser{nodes: make([]Node, 0)}
+
+	trimNext := false // Set by a right trim marker for the following text
+	var pos position  // Position of the data read so far
+	for {
+		text, found, err := readUntil(br, openMarker)
+		if err != nil {
+			return nil, err
+		}
+		pos.advance(text)
+		start := pos
+
+		var token string
+		if found {
+			token, found, err = readToken(br)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				// An unclosed marker is plain text, as in ParseString
+				text += openMarker + token
+			}
+			pos.advance(openMarker + token + closeMarker)
+		}
+
+		if !found {
+			if trimNext {
+				text = strings.TrimLeft(text, whitespace)
+			}
+			if len(text) > 0 {
+				p.nodes = append(p.nodes, &TextNode{Text: text})
+			}
+			break
+		}
+
+		token, trimLeft, trimRight := trimMarkers(token)
+
+		// Add text before template if any
+		if trimNext {
+			text = strings.TrimLeft(text, whitespace)
+		}
+		if trimLeft {
+			text = strings.TrimRight(text, whitespace)
+		}
+		if len(text) > 0 {
+			p.nodes = append(p.nodes, &TextNode{Text: text})
+		}
+		trimNext = trimRight
+
+		locate := func() *ParseError {
+			return &ParseError{Offset: start.offset, Line: start.line + 1, Column: start.column + 1}
+		}
+		isBlock, err := p.block(token, locate)
+		if !isBlock && err == nil {
+			p.nodes, err = appendToken(p.nodes, token, nil)
+		}
+		if err != nil {
+			at := locate()
+			at.Err = err
+			return nil, at
+		}
+	}
+
+	nodes, err := p.finish()
+	if err != nil {
+		return nil, err
+	}
+	return &Template{
+		Nodes: nodes,
+	}, nil
+}
+
+// readUntil reads from br up to the first occurrence of marker and returns the data before it.
+// Markers split across buffer refills are found since the data is accumulated before matching.
+// If the input ends first, found is false and all remaining data is returned.
+func readUntil(br *bufio.Reader, marker string) (data string, found bool, err error) {
+	var b strings.Builder
+	last := marker[len(marker)-1]
+	for {
+		chunk, err := br.ReadString(last)
+		b.WriteString(chunk)
+		if err == io.EOF {
+			return b.String(), false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if data := b.String(); strings.HasSuffix(data, marker) {
+			return data[:len(data)-len(marker)], true, nil
+		}
+	}
+}
+
+// readToken reads the rest of a token whose opening marker was read, up to
+// its closing marker as found by closeToken, and returns the data before it.
+// If the input ends first, found is false and all remaining data is returned.
+func readToken(br *bufio.Reader) (token string, found bool, err error) {
+	var b strings.Builder
+	for {
+		data, found, err := readUntil(br, closeMarker)
+		if err != nil {
+			return "", false, err
+		}
+		b.WriteString(data)
+		if !found {
+			return b.String(), false, nil
+		}
+		b.WriteString(closeMarker)
+		// A closing marker of a nested token ends a partial token only
+		if end, ok := closeToken(b.String()); ok {
+			return b.String()[:end-len(closeMarker)], true, nil
+		}
+	}
+}
+
+// position tracks the offset, line and column reached in a stream, starting at 0.
+type position struct {
+	offset int
+	line   int
+	column int
+}
+
+// advance moves the position past s.
+func (p *position) advance(s string) {
+	p.offset += len(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.line += strings.Count(s, "\n")
+		p.column = len(s) - i - 1
+	} else {
+		p.column += len(s)
+	}
+}
+
+// Reader returns a reader rendering the template with r on demand, one node
+// at a time, so the output can be copied without rendering it all first.
+// A node that fails to render makes Read return its error once the output
+// of the previous nodes has been read.
+func (t *Template) Reader(r Replacer) io.Reader {
+	return &templateReader{nodes: t.Nodes, r: memoize(r)}
+}
+
+// templateReader renders the next node into buf whenever buf runs empty.
+type templateReader struct {
+	nodes []Node
+	r     Replacer
+	buf   bytes.Buffer
+	err   error
+}
+
+func (tr *templateReader) Read(p []byte) (int, error) {
+	for tr.buf.Len() == 0 {
+		if tr.err != nil {
+			return 0, tr.err
+		}
+		if len(tr.nodes) == 0 {
+			return 0, io.EOF
+		}
+		if err := tr.nodes[0].WriteTo(&tr.buf, tr.r); err != nil {
+			// Drop the partial output of the failed node
+			tr.buf.Reset()
+			tr.err = err
+		}
+		tr.nodes = tr.nodes[1:]
+	}
+	return tr.buf.Read(p)
+}
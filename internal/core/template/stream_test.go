@@ -0,0 +1,100 @@
+package template
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStreamMatchesParseString(t *testing.T) {
+	sources := []string{
+		"",
+		"plain text only",
+		"Hello {{.name}}!",
+		"{{.type:feat|fix|@docs}}({{.scope:@core}}): {{.desc}}",
+		"a {{- .x -}} b\n{{ .y -}}\n\n  c",
+		"{{.x}}} and }}",
+		"unclosed {{.x and more text",
+		"text } with { braces }} and {{.x}}",
+		"{{date \"2006\"}} {{.ticket:/^[A-Z]+-[0-9]+$/}} {{.desc:len=1..10}}",
+		strings.Repeat("line {{.key}} with {{.other:a|@b}}\n", 50),
+	}
+
+	for _, src := range sources {
+		want, err := ParseString(src)
+		require.NoError(t, err)
+
+		// The smallest bufio buffer and single-byte reads make markers span reads
+		got, err := parseStream(bufio.NewReaderSize(iotest.OneByteReader(strings.NewReader(src)), 16))
+		require.NoError(t, err, src)
+		assert.Equal(t, want, got, src)
+
+		got, err = ParseStream(strings.NewReader(src))
+		require.NoError(t, err, src)
+		assert.Equal(t, want, got, src)
+	}
+}
+
+func TestParseStreamMarkerAtBufferBoundary(t *testing.T) {
+	// Place the opening and closing markers across the 16 byte buffer boundary
+	for pad := 10; pad < 20; pad++ {
+		src := strings.Repeat("x", pad) + "{{.key}}" + strings.Repeat("y", pad) + "{{.other}}"
+
+		got, err := parseStream(bufio.NewReaderSize(strings.NewReader(src), 16))
+		require.NoError(t, err)
+
+		out, err := got.Execute(ReplacerFuncFromMap(map[string]string{"key": "K", "other": "O"}))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("x", pad)+"K"+strings.Repeat("y", pad)+"O", out)
+	}
+}
+
+func TestParseStreamErrors(t *testing.T) {
+	_, err := ParseStream(strings.NewReader(`{{include "header.tmpl"}}`))
+	assert.ErrorIs(t, err, ErrIncludeUnsupported)
+
+	_, err = ParseStream(strings.NewReader("{{.x:len=oops}}"))
+	assert.ErrorIs(t, err, ErrInvalidLength)
+
+	readErr := errors.New("read failed")
+	_, err = ParseStream(iotest.ErrReader(readErr))
+	assert.ErrorIs(t, err, readErr)
+}
+
+func TestReader(t *testing.T) {
+	tmpl, err := ParseString(largeTemplate)
+	require.NoError(t, err)
+	r := ReplacerFuncFromMap(benchReplacements)
+
+	expected, err := tmpl.Execute(r)
+	require.NoError(t, err)
+
+	var got bytes.Buffer
+	chunk := make([]byte, 7)
+	reader := tmpl.Reader(r)
+	for {
+		n, err := reader.Read(chunk)
+		got.Write(chunk[:n])
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, expected, got.String())
+}
+
+func TestReaderError(t *testing.T) {
+	tmpl, err := ParseString("Hello {{.name}} and {{.missing}}!")
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(tmpl.Reader(ReplacerFuncFromMap(map[string]string{"name": "World"})))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+	assert.Equal(t, "Hello World and ", string(got))
+}
@@ -5,6 +5,10 @@ package template
 import (
 	"bytes"
 	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -13,11 +17,26 @@ const (
 	openMarker  = "{{"
 	closeMarker = "}}"
 
+	// Whitespace trim marker: {{- .key -}}
+	trimMark   = "-"
+	whitespace = " \t\r\n"
+
+	// Include keyword: {{include "file"}}
+	includeKeyword = "include"
+
 	// Variable markers
-	varPrefix   = "."
-	choiceSep   = ":"
-	choiceDelim = "|"
-	defPrefix   = "@"
+	varPrefix    = "."
+	choiceSep    = ":"
+	choiceDelim  = "|"
+	defPrefix    = "@"
+	filterSep    = "|"
+	patternMark  = "/"
+	lenPrefix    = "len="
+	rangeSep     = ".."
+	optionalMark = "?"
+	quoteMark    = `"`
+	descMark     = "#desc="
+	aliasMark    = "="
 )
 
 // Node represents a part of the template: either text or a placeholder.
@@ -38,7 +57,15 @@ type Template struct {
 // It reads the entire content of r into memory before parsing.
 // Returns an error if reading fails.
 //
-// Syntax: {{.key}} or {{.key:choice1|choice2|@default}}
+// Syntax: {{.key}}, {{.key:choice1|choice2|@default}}, {{.key:/regexp/}} or {{.key:len=min..max}}.
+// Filters follow the key before any constraint: {{.desc|capitalize}} or {{.desc|capitalize:@wip}}.
+// A key ending in ? is optional and renders empty when missing: {{.scope?}}.
+// Writing {{- or -}} trims the whitespace before or after the token.
+// Built-in functions are called as {{date "2006-01-02"}}.
+// {{if .breaking}}...{{else}}...{{end}} renders a branch depending on a yes/no variable;
+// {{if .scope?}} selects the first branch whenever the variable is non-empty.
+//
+// Includes are not supported since there is no file to resolve them against; use ParseFile.
 func Parse(r io.Reader) (*Template, error) {
 	buf := new(bytes.Buffer)
 	if _, err := buf.ReadFrom(r); err != nil {
@@ -48,7 +75,8 @@ func Parse(r io.Reader) (*Template, error) {
 }
 
 // findNextTemplate finds the next template expression in the string.
-// It searches for the pattern {{...}} starting from startPos.
+// It searches for the pattern {{...}} starting from startPos, skipping over
+// the expressions nested in computed defaults.
 // Returns the start and end positions of the template, and whether it was found.
 func findNextTemplate(data string, startPos int) (start, end int, found bool) {
 	openPos := strings.Index(data[startPos:], openMarker)
@@ -57,103 +85,476 @@ func findNextTemplate(data string, startPos int) (start, end int, found bool) {
 	}
 	openPos += startPos
 
-	closePos := strings.Index(data[openPos+len(openMarker):], closeMarker)
-	if closePos < 0 {
+	tokenStart := openPos + len(openMarker)
+	end, found = closeToken(data[tokenStart:])
+	if !found {
 		return 0, 0, false
 	}
-	closePos += openPos + len(openMarker)
+	return openPos, tokenStart + end, true
+}
 
-	return openPos, closePos + len(closeMarker), true
+// closeToken returns the position in s just past the closing marker of the
+// token s starts, s beginning right after its opening marker. Opening markers
+// count as nested tokens only after the default prefix of the token holding
+// them, as in {{.title:@{{.type}}: changes}}; elsewhere they are plain text.
+// found is false when the token is not closed in s.
+func closeToken(s string) (end int, found bool) {
+	starts := []int{0} // Start of each open token, innermost last
+	pos := 0
+	for len(starts) > 0 {
+		closePos := strings.Index(s[pos:], closeMarker)
+		if closePos < 0 {
+			return 0, false
+		}
+		nested := strings.Index(s[pos:], openMarker)
+		if nested >= 0 && nested < closePos && strings.Contains(s[starts[len(starts)-1]:pos+nested], defPrefix) {
+			pos += nested + len(openMarker)
+			starts = append(starts, pos)
+			continue
+		}
+		pos += closePos + len(closeMarker)
+		starts = starts[:len(starts)-1]
+	}
+	return pos, true
 }
 
 // ParseString parses a template string directly.
 // It is more efficient than Parse when the input is already a string.
-// Returns an error if the template syntax is invalid.
+// Returns a *ParseError locating the failing token if the template syntax is invalid.
+// Includes are not supported since there is no file to resolve them against; use ParseFile.
 //
 // Example:
 //
 //	tmpl, err := ParseString("Hello {{.name}}!")
 func ParseString(data string) (*Template, error) {
-	nodes := make([]Node, 0, len(data)/10) // Estimate initial capacity
+	// Plain text needs no scanning
+	if !strings.Contains(data, openMarker) {
+		if data == "" {
+			return &Template{Nodes: []Node{}}, nil
+		}
+		return &Template{Nodes: []Node{&TextNode{Text: data}}}, nil
+	}
+
+	nodes, err := parseNodes(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template{
+		Nodes: nodes,
+	}, nil
+}
+
+// parseNodes splits data into nodes, expanding include tokens with include.
+// A nil include makes include tokens an error.
+// Errors of a token are reported as a *ParseError locating it in data.
+// Trim markers ({{- and -}}) remove the whitespace of the adjacent text.
+func parseNodes(data string, include includeFunc) ([]Node, error) {
+	p := blockParser{nodes: make([]Node, 0, len(data)/10)} // Estimate initial capacity
 
 	pos := 0
+	trimNext := false // Set by a right trim marker for the following text
 	for {
 		// Find next template expression
 		start, end, found := findNextTemplate(data, pos)
 		if !found {
 			// No more templates, add remaining text if any
-			if len(data[pos:]) > 0 {
-				nodes = append(nodes, &TextNode{Text: data[pos:]})
+			text := data[pos:]
+			if trimNext {
+				text = strings.TrimLeft(text, whitespace)
+			}
+			if len(text) > 0 {
+				p.nodes = append(p.nodes, &TextNode{Text: text})
 			}
 			break
 		}
 
+		// Extract template token and its trim markers
+		token, trimLeft, trimRight := trimMarkers(data[start+len(openMarker) : end-len(closeMarker)])
+
 		// Add text before template if any
-		if start > pos {
-			nodes = append(nodes, &TextNode{Text: data[pos:start]})
+		text := data[pos:start]
+		if trimNext {
+			text = strings.TrimLeft(text, whitespace)
+		}
+		if trimLeft {
+			text = strings.TrimRight(text, whitespace)
+		}
+		if len(text) > 0 {
+			p.nodes = append(p.nodes, &TextNode{Text: text})
 		}
+		trimNext = trimRight
 
-		// Extract and parse template token
-		token := data[start+len(openMarker) : end-len(closeMarker)]
-		node, err := parseToken(token)
+		// Parse template token
+		isBlock, err := p.block(token, func() *ParseError { return newParseError(data, start, nil) })
+		if !isBlock && err == nil {
+			p.nodes, err = appendToken(p.nodes, token, include)
+		}
+		if err != nil {
+			return nil, newParseError(data, start, err)
+		}
+		pos = end
+	}
+
+	return p.finish()
+}
+
+// appendToken parses a token stripped of its markers and appends its nodes,
+// expanding includes with include. A nil include makes include tokens an error.
+func appendToken(nodes []Node, token string, include includeFunc) ([]Node, error) {
+	if name, ok, err := parseInclude(token); ok {
+		if err != nil {
+			return nil, err
+		}
+		if include == nil {
+			return nil, NewIncludeUnsupportedError(name)
+		}
+		included, err := include(name)
 		if err != nil {
 			return nil, err
 		}
+		return append(nodes, included...), nil
+	}
 
-		nodes = append(nodes, node)
-		pos = end
+	node, err := parseToken(token)
+	if err != nil {
+		return nil, err
 	}
+	return append(nodes, node), nil
+}
 
-	return &Template{
-		Nodes: nodes,
-	}, nil
+// trimMarkers strips the trim markers from token and reports which were present.
+// As in text/template, a marker must be separated from the token body by whitespace.
+func trimMarkers(token string) (body string, left, right bool) {
+	if len(token) > len(trimMark) && strings.HasPrefix(token, trimMark) && isSpace(token[len(trimMark)]) {
+		token = token[len(trimMark):]
+		left = true
+	}
+	if len(token) > len(trimMark) && strings.HasSuffix(token, trimMark) && isSpace(token[len(token)-len(trimMark)-1]) {
+		token = token[:len(token)-len(trimMark)]
+		right = true
+	}
+	return token, left, right
+}
+
+func isSpace(c byte) bool {
+	return strings.IndexByte(whitespace, c) >= 0
 }
 
 // parseToken parses a single template token into a Node.
-// It handles both simple variables and variables with choices.
+// It handles simple variables, variables with constraints and function calls.
 // Returns an error if the token syntax is invalid.
+//
+// Choices and defaults containing delimiters are written as quoted Go strings:
+// {{.url:@"https://example.com/a|b"}}.
+//
+// A default given next to choices is itself a valid choice: {{.type:feat|fix|@docs}}
+// accepts feat, fix and docs. A default alone, as in {{.name:@World}}, declares no
+// choices and leaves the value free.
+//
+// Choices registered with RegisterEnum are referenced by name: {{.type:@enum:committype}}
+// or, with a default, {{.type:@enum:committype|@feat}}. Choices computed by a
+// provider registered with RegisterChoiceProvider are referenced as {{.scope:@cmd:dirs}}.
+//
+// A choice may name a shorter alias accepted in its place: {{.type:feat=f|fix=x}}
+// renders feat for the value f. Quoted choices are taken as they are.
+//
+// A default containing tokens is computed from other variables when used:
+// {{.title:@{{.type}}: changes}}. It is not added to the choices.
+//
+// A description for the person filling in the value ends the token:
+// {{.scope?#desc=the affected module}}.
 func parseToken(token string) (Node, error) {
 	t := strings.TrimSpace(token)
 	if !strings.HasPrefix(t, varPrefix) {
-		return nil, NewInvalidTokenSyntaxError(token)
+		return parseFunc(token)
 	}
 
-	body := t[len(varPrefix):]
-	var key, def string
+	body, desc, _ := cutOutside(t[len(varPrefix):], descMark)
+	v, err := parseVar(body, token)
+	if err != nil {
+		return nil, err
+	}
+	v.Desc = strings.TrimSpace(desc)
+	return v, nil
+}
+
+// parseVar parses the body of a variable token, after the dot and without its description.
+func parseVar(body, token string) (*VarNode, error) {
+	keyPart, rest, hasConstraint := strings.Cut(body, choiceSep)
+	key, filters, err := parseKey(keyPart, token)
+	if err != nil {
+		return nil, err
+	}
+	key, optional := strings.CutSuffix(key, optionalMark)
+
+	var def string
+	var defNodes []Node
 	choices := make([]string, 0, 4) // Pre-allocate for common case
+	var aliases map[string]string
 	hasDef := false
 
-	if idx := strings.Index(body, choiceSep); idx >= 0 {
-		key = strings.TrimSpace(body[:idx])
-		rest := body[idx+len(choiceSep):]
-		parts := strings.Split(rest, choiceDelim)
+	if hasConstraint {
+		rest = strings.TrimSpace(rest)
 
-		for _, p := range parts {
+		// Constraints other than choices take the whole rest of the token
+		switch {
+		case len(rest) >= 2*len(patternMark) && strings.HasPrefix(rest, patternMark) && strings.HasSuffix(rest, patternMark):
+			pattern, err := parsePattern(rest[len(patternMark) : len(rest)-len(patternMark)])
+			if err != nil {
+				return nil, err
+			}
+			return &VarNode{Key: key, Choices: choices, Pattern: pattern, Filters: filters, Optional: optional}, nil
+		case strings.HasPrefix(rest, lenPrefix):
+			minLen, maxLen, err := parseLength(rest[len(lenPrefix):])
+			if err != nil {
+				return nil, err
+			}
+			return &VarNode{Key: key, Choices: choices, MinLen: minLen, MaxLen: maxLen, Filters: filters, Optional: optional}, nil
+		}
+
+		explicit := false
+		for more := true; more; {
+			var p string
+			p, rest, more = cutOutside(rest, choiceDelim)
 			p = strings.TrimSpace(p)
-			if strings.HasPrefix(p, defPrefix) {
-				def = p[len(defPrefix):]
+			if set, ok, err := referencedChoices(p); ok {
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range set {
+					if !slices.Contains(choices, c) {
+						choices = append(choices, c)
+					}
+				}
+				explicit = true
+				continue
+			}
+			isDef := strings.HasPrefix(p, defPrefix)
+			if isDef {
+				p = p[len(defPrefix):]
+			}
+			p, alias, hasAlias := cutOutside(p, aliasMark)
+			if hasAlias {
+				p = strings.TrimSpace(p)
+			}
+			if p, err = unquoteChoice(p, token); err != nil {
+				return nil, err
+			}
+			if hasAlias {
+				if aliases, err = addAlias(aliases, alias, p, token); err != nil {
+					return nil, err
+				}
+			}
+			if isDef {
+				def = p
 				hasDef = true
-				choices = append(choices, def)
+				if strings.Contains(p, openMarker) {
+					if defNodes, err = parseNodes(p, nil); err != nil {
+						return nil, err
+					}
+					continue
+				}
 			} else {
+				explicit = true
+			}
+			if !slices.Contains(choices, p) {
 				choices = append(choices, p)
 			}
 		}
-	} else {
-		key = strings.TrimSpace(body)
+		if !explicit {
+			// A lone default does not restrict the value
+			choices = choices[:0]
+		}
 	}
 
 	return &VarNode{
-		Key:     key,
-		Choices: choices,
-		Default: def,
-		HasDef:  hasDef,
+		Key:          key,
+		Choices:      choices,
+		Default:      def,
+		DefaultNodes: defNodes,
+		HasDef:       hasDef,
+		Aliases:      aliases,
+		Filters:      filters,
+		Optional:     optional,
 	}, nil
 }
 
+// addAlias records alias as standing for choice, creating aliases if needed.
+// An alias must not be empty nor stand for two different choices.
+func addAlias(aliases map[string]string, alias, choice, token string) (map[string]string, error) {
+	alias, err := unquoteChoice(strings.TrimSpace(alias), token)
+	if err != nil {
+		return nil, err
+	}
+	if other, ok := aliases[alias]; alias == "" || ok && other != choice {
+		return nil, NewInvalidTokenSyntaxError(token)
+	}
+	if aliases == nil {
+		aliases = make(map[string]string)
+	}
+	aliases[alias] = choice
+	return aliases, nil
+}
+
+// cutOutside slices s around the first sep, ignoring those inside quoted
+// values and nested tokens, e.g. to scan choices one by one without allocating.
+// Like strings.Cut it reports whether sep was found.
+func cutOutside(s, sep string) (before, after string, found bool) {
+	quoted, depth := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++ // Skip the escaped byte
+		case strings.HasPrefix(s[i:], quoteMark):
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(s[i:], openMarker):
+			depth++
+			i += len(openMarker) - 1
+		case !quoted && depth > 0 && strings.HasPrefix(s[i:], closeMarker):
+			depth--
+			i += len(closeMarker) - 1
+		case !quoted && depth == 0 && strings.HasPrefix(s[i:], sep):
+			return s[:i], s[i+len(sep):], true
+		}
+	}
+	return s, "", false
+}
+
+// unquoteChoice returns the literal value of a choice or default written as
+// a Go string literal, such as "a:b|c", and any other choice as is.
+func unquoteChoice(choice, token string) (string, error) {
+	if !strings.HasPrefix(choice, quoteMark) {
+		return choice, nil
+	}
+	value, err := strconv.Unquote(choice)
+	if err != nil {
+		return "", NewInvalidTokenSyntaxError(token)
+	}
+	return value, nil
+}
+
+// parsePattern compiles the expression of a /regexp/ constraint.
+func parsePattern(expr string) (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, NewInvalidPatternError(expr, err)
+	}
+	return pattern, nil
+}
+
+// parseLength parses the "min..max" range of a len= constraint.
+// Either bound may be omitted, which leaves it unbounded (reported as 0).
+func parseLength(spec string) (minLen, maxLen int, err error) {
+	lo, hi, ok := strings.Cut(spec, rangeSep)
+	if !ok || (lo == "" && hi == "") {
+		return 0, 0, NewInvalidLengthError(spec)
+	}
+	if lo != "" {
+		if minLen, err = strconv.Atoi(lo); err != nil || minLen < 0 {
+			return 0, 0, NewInvalidLengthError(spec)
+		}
+	}
+	if hi != "" {
+		if maxLen, err = strconv.Atoi(hi); err != nil || maxLen < 1 || maxLen < minLen {
+			return 0, 0, NewInvalidLengthError(spec)
+		}
+	}
+	return minLen, maxLen, nil
+}
+
+// Variables returns the variable nodes of the template in order of appearance.
+// A key used several times is returned once per use.
+func (t *Template) Variables() []*VarNode {
+	return appendVariables(make([]*VarNode, 0, len(t.Nodes)/2), t.Nodes)
+}
+
+// appendVariables appends the variable nodes of nodes to vars, descending into
+// if blocks: the condition, then both branches
+func appendVariables(vars []*VarNode, nodes []Node) []*VarNode {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *VarNode:
+			vars = append(vars, n)
+		case *IfNode:
+			vars = append(vars, n.Cond)
+			vars = appendVariables(vars, n.Then)
+			vars = appendVariables(vars, n.Else)
+		}
+	}
+	return vars
+}
+
+// String reconstructs the template source from its nodes in canonical form.
+// Parsing the result yields equivalent nodes. Includes appear expanded and
+// whitespace removed by trim markers is gone.
+func (t *Template) String() string {
+	var b strings.Builder
+	writeNodes(&b, t.Nodes)
+	return b.String()
+}
+
+// Clone returns a deep copy of the template whose nodes can be modified,
+// e.g. to override a default, without affecting t or other clones.
+// Compiled patterns are shared since they are immutable.
+func (t *Template) Clone() *Template {
+	return &Template{Nodes: cloneNodes(t.Nodes)}
+}
+
+// cloneNodes deep copies nodes, keeping a nil slice nil
+func cloneNodes(src []Node) []Node {
+	if src == nil {
+		return nil
+	}
+	nodes := make([]Node, len(src))
+	for i, node := range src {
+		switch n := node.(type) {
+		case *TextNode:
+			c := *n
+			nodes[i] = &c
+		case *VarNode:
+			c := *n
+			c.Choices = slices.Clone(n.Choices)
+			c.Filters = slices.Clone(n.Filters)
+			c.Aliases = maps.Clone(n.Aliases)
+			c.DefaultNodes = cloneNodes(n.DefaultNodes)
+			nodes[i] = &c
+		case *FuncNode:
+			c := *n
+			c.Args = slices.Clone(n.Args)
+			nodes[i] = &c
+		case *IfNode:
+			cond := cloneNodes([]Node{n.Cond})[0].(*VarNode)
+			nodes[i] = &IfNode{Cond: cond, Then: cloneNodes(n.Then), Else: cloneNodes(n.Else)}
+		default:
+			nodes[i] = node
+		}
+	}
+	return nodes
+}
+
+// plainText returns the text of a template made of a single text node,
+// which renders without a replacer
+func (t *Template) plainText() (string, bool) {
+	if len(t.Nodes) != 1 {
+		return "", false
+	}
+	text, ok := t.Nodes[0].(*TextNode)
+	if !ok {
+		return "", false
+	}
+	return text.Text, true
+}
+
 // Execute renders the template to a string using the provided Replacer.
 // It processes all nodes in sequence and returns the final string.
 // Returns an error if any node fails to render.
 func (t *Template) Execute(r Replacer) (string, error) {
+	if text, ok := t.plainText(); ok {
+		return text, nil
+	}
+	r = memoize(r)
+
 	var out strings.Builder
 	out.Grow(len(t.Nodes) * 32) // Estimate average node size
 
@@ -165,10 +566,48 @@ func (t *Template) Execute(r Replacer) (string, error) {
 	return out.String(), nil
 }
 
+// ExecuteEach renders the template once per Replacer, e.g. for batch commits.
+// Both results are indexed like rs: a failed render leaves an empty message and
+// its error, without stopping the others. errs is nil when every render succeeded.
+func (t *Template) ExecuteEach(rs []Replacer) (messages []string, errs []error) {
+	messages = make([]string, len(rs))
+	for i, r := range rs {
+		msg, err := t.Execute(r)
+		if err != nil {
+			if errs == nil {
+				errs = make([]error, len(rs))
+			}
+			errs[i] = err
+			continue
+		}
+		messages[i] = msg
+	}
+	return messages, errs
+}
+
+// ExecuteBytes renders the template to a byte slice using the provided Replacer.
+// It avoids the string conversion of Execute for callers that need bytes.
+// Returns an error if any node fails to render.
+func (t *Template) ExecuteBytes(r Replacer) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(t.Nodes) * 32) // Estimate average node size
+
+	if err := t.ExecuteTo(&buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // ExecuteTo writes the rendered template directly to the provided writer.
 // It is more efficient than Execute when you want to write directly to a file or network connection.
 // Returns an error if any node fails to render.
 func (t *Template) ExecuteTo(w io.Writer, r Replacer) error {
+	if text, ok := t.plainText(); ok {
+		_, err := io.WriteString(w, text)
+		return err
+	}
+	r = memoize(r)
+
 	for _, node := range t.Nodes {
 		if err := node.WriteTo(w, r); err != nil {
 			return err
@@ -176,3 +615,25 @@ func (t *Template) ExecuteTo(w io.Writer, r Replacer) error {
 	}
 	return nil
 }
+
+// NodeHook observes a node after it rendered, with its output or error.
+type NodeHook func(node Node, rendered string, err error)
+
+// ExecuteWithHook renders the template like Execute, calling hook after each
+// node with what the node rendered, e.g. for tracing. Rendering stops at the
+// first failing node, once hook has seen its error.
+func (t *Template) ExecuteWithHook(r Replacer, hook NodeHook) (string, error) {
+	r = memoize(r)
+
+	var out, node strings.Builder
+	for _, n := range t.Nodes {
+		node.Reset()
+		err := n.WriteTo(&node, r)
+		hook(n, node.String(), err)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(node.String())
+	}
+	return out.String(), nil
+}
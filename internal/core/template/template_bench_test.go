@@ -20,6 +20,7 @@ var (
 	choiceTemplate     = "Hello {{.greeting:Hi|Hello|@Hey}} {{.name}}!"
 	defaultTemplate    = "Hello {{.name:@World}}!"
 	largeTemplate     = generateLargeTemplate(1000)
+	plainTemplate      = strings.Repeat("Line of plain text without any variables\n", 1000)
 )
 
 // generateLargeTemplate creates a large template with multiple variables
@@ -47,6 +48,7 @@ func BenchmarkParseString(b *testing.B) {
 		{"WithChoices", choiceTemplate},
 		{"WithDefault", defaultTemplate},
 		{"Large", largeTemplate},
+		{"LargePlain", plainTemplate},
 	}
 
 	for _, bm := range benchmarks {
@@ -62,6 +64,16 @@ func BenchmarkParseString(b *testing.B) {
 	}
 }
 
+// BenchmarkParseTokenChoices counts the allocations of parsing a token with choices
+func BenchmarkParseTokenChoices(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseToken(".greeting:Hi|Hello|@Hey"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkExecute(b *testing.B) {
 	templates := []struct {
 		name     string
@@ -72,6 +84,7 @@ func BenchmarkExecute(b *testing.B) {
 		{"WithChoices", choiceTemplate},
 		{"WithDefault", defaultTemplate},
 		{"Large", largeTemplate},
+		{"LargePlain", plainTemplate},
 	}
 
 	for _, tmpl := range templates {
@@ -95,6 +108,40 @@ func BenchmarkExecute(b *testing.B) {
 	}
 }
 
+func BenchmarkExecuteBytes(b *testing.B) {
+	templates := []struct {
+		name     string
+		template string
+	}{
+		{"Simple", simpleTemplate},
+		{"Complex", complexTemplate},
+		{"WithChoices", choiceTemplate},
+		{"WithDefault", defaultTemplate},
+		{"Large", largeTemplate},
+		{"LargePlain", plainTemplate},
+	}
+
+	for _, tmpl := range templates {
+		b.Run(tmpl.name, func(b *testing.B) {
+			t, err := ParseString(tmpl.template)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			replacer := ReplacerFuncFromMap(benchReplacements)
+			b.ResetTimer()
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				_, err := t.ExecuteBytes(replacer)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkExecuteTo(b *testing.B) {
 	templates := []struct {
 		name     string
@@ -105,6 +152,7 @@ func BenchmarkExecuteTo(b *testing.B) {
 		{"WithChoices", choiceTemplate},
 		{"WithDefault", defaultTemplate},
 		{"Large", largeTemplate},
+		{"LargePlain", plainTemplate},
 	}
 
 	for _, tmpl := range templates {
@@ -1,9 +1,11 @@
 package template
 
 import (
+	"bufio"
 	"bytes"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -108,6 +110,38 @@ var parseTests = []testCase{
 		parseErr:     true,
 		executeErr:   false,
 	},
+	{
+		name:         "Matching pattern",
+		template:     "Refs: {{.ticket:/^[A-Z]+-\\d+$/}}",
+		replacements: map[string]string{"ticket": "ABC-123"},
+		want:         "Refs: ABC-123",
+		parseErr:     false,
+		executeErr:   false,
+	},
+	{
+		name:         "Pattern with alternation",
+		template:     "{{.env: /^(dev|prod)$/ }}",
+		replacements: map[string]string{"env": "prod"},
+		want:         "prod",
+		parseErr:     false,
+		executeErr:   false,
+	},
+	{
+		name:         "Non-matching pattern",
+		template:     "Refs: {{.ticket:/^[A-Z]+-\\d+$/}}",
+		replacements: map[string]string{"ticket": "abc-123"},
+		want:         "",
+		parseErr:     false,
+		executeErr:   true,
+	},
+	{
+		name:         "Invalid pattern",
+		template:     "Refs: {{.ticket:/^[A-Z+$/}}",
+		replacements: map[string]string{"ticket": "ABC-123"},
+		want:         "",
+		parseErr:     true,
+		executeErr:   false,
+	},
 	{
 		name:         "Invalid choice syntax",
 		template:     "Hello {{.name:choice1|choice2|@default|invalid}}!",
@@ -176,6 +210,100 @@ func TestParseFromReader(t *testing.T) {
 	}
 }
 
+func TestPatternErrors(t *testing.T) {
+	_, err := ParseString("{{.ticket:/^[A-Z+$/}}")
+	require.ErrorIs(t, err, ErrInvalidPattern)
+
+	tmpl, err := ParseString("{{.ticket:/^[A-Z]+-\\d+$/}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"ticket": "nope"}))
+	require.ErrorIs(t, err, ErrInvalidValue)
+	assert.Contains(t, err.Error(), `must match /^[A-Z]+-\d+$/`)
+}
+
+func TestLengthConstraint(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		value   string
+		wantErr bool
+	}{
+		{"Within bounds", "len=1..5", "auth", false},
+		{"At upper bound", "len=1..5", "login", false},
+		{"Over upper bound", "len=1..5", "session", true},
+		{"Under lower bound", "len=3..5", "ab", true},
+		{"Empty under lower bound", "len=1..5", "", true},
+		{"Only upper bound", "len=..3", "", false},
+		{"Only lower bound", "len=3..", "authentication", false},
+		{"Counts runes", "len=..3", "äöü", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString("{{.scope:" + tc.token + "}}")
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": tc.value}))
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrInvalidValue)
+				assert.Contains(t, err.Error(), `"scope"`)
+				assert.Contains(t, err.Error(), tc.token[len("len="):])
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestLengthConstraintMalformed(t *testing.T) {
+	for _, spec := range []string{"len=", "len=..", "len=5", "len=a..3", "len=1..b", "len=-1..3", "len=5..2", "len=..0"} {
+		_, err := ParseString("{{.scope:" + spec + "}}")
+		assert.ErrorIs(t, err, ErrInvalidLength, spec)
+	}
+}
+
+func TestTrimMarkers(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string
+		replacements map[string]string
+		want         string
+	}{
+		{"Present without markers", "feat ( {{.scope:@}} ) : x", map[string]string{"scope": "auth"}, "feat ( auth ) : x"},
+		{"Absent without markers", "feat ( {{.scope:@}} ) : x", nil, "feat (  ) : x"},
+		{"Present with markers", "feat ( {{- .scope:@ -}} ) : x", map[string]string{"scope": "auth"}, "feat (auth) : x"},
+		{"Absent with markers", "feat ( {{- .scope:@ -}} ) : x", nil, "feat () : x"},
+		{"Left marker only", "a \n\t {{- .v}} b", map[string]string{"v": "1"}, "a1 b"},
+		{"Right marker only", "a {{.v -}} \n b", map[string]string{"v": "1"}, "a 1b"},
+		{"Markers at template edges", "  {{- .v -}}  ", map[string]string{"v": "1"}, "1"},
+		{"Adjacent markers", "{{.a -}}   {{- .b}}", map[string]string{"a": "1", "b": "2"}, "12"},
+		{"Dash without space is not a marker", "{{.v:@-}}", nil, "-"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString(tc.template)
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(ReplacerFuncFromMap(tc.replacements))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat|@fix}}: {{.desc}} {{.type}}")
+	require.NoError(t, err)
+
+	vars := tmpl.Variables()
+	require.Len(t, vars, 3)
+	assert.Equal(t, &VarNode{Key: "type", Choices: []string{"feat", "fix"}, Default: "fix", HasDef: true}, vars[0])
+	assert.Equal(t, "desc", vars[1].Key)
+	assert.Equal(t, "type", vars[2].Key)
+}
+
 func TestExecuteTo(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -217,6 +345,29 @@ func TestExecuteTo(t *testing.T) {
 	}
 }
 
+func TestExecuteBytes(t *testing.T) {
+	for _, tc := range parseTests {
+		if tc.parseErr {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString(tc.template)
+			require.NoError(t, err)
+
+			replacer := ReplacerFuncFromMap(tc.replacements)
+			want, wantErr := tmpl.Execute(replacer)
+			got, err := tmpl.ExecuteBytes(replacer)
+			if wantErr != nil {
+				require.EqualError(t, err, wantErr.Error())
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, string(got))
+		})
+	}
+}
+
 func TestConcurrentExecution(t *testing.T) {
 	tmpl, err := ParseString("Hello {{.name}}!")
 	require.NoError(t, err)
@@ -259,3 +410,527 @@ func TestConcurrentExecuteTo(t *testing.T) {
 		<-done
 	}
 }
+
+func TestDefaultIsChoice(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		wantChoices []string
+	}{
+		{name: "Default in set", template: "{{.type:feat|@fix}}", wantChoices: []string{"feat", "fix"}},
+		{name: "Default also listed", template: "{{.type:feat|fix|@fix}}", wantChoices: []string{"feat", "fix"}},
+		{name: "Default out of set", template: "{{.type:feat|fix|@docs}}", wantChoices: []string{"feat", "fix", "docs"}},
+		{name: "Default alone", template: "{{.type:@feat}}", wantChoices: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseString(tt.template)
+			require.NoError(t, err)
+			require.Len(t, tmpl.Variables(), 1)
+			assert.Equal(t, tt.wantChoices, tmpl.Variables()[0].Choices)
+		})
+	}
+}
+
+func TestExecuteDefaultChoice(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat|fix|@docs}}")
+	require.NoError(t, err)
+
+	// The default is rendered when nothing is provided
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{}))
+	require.NoError(t, err)
+	assert.Equal(t, "docs", got)
+
+	// and is accepted when provided explicitly
+	got, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "docs"}))
+	require.NoError(t, err)
+	assert.Equal(t, "docs", got)
+
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "chore"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	// A lone default leaves the value free
+	tmpl, err = ParseString("{{.name:@World}}")
+	require.NoError(t, err)
+	got, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"name": "Gopher"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Gopher", got)
+}
+
+func TestDefaultValidatedAgainstChoices(t *testing.T) {
+	empty := ReplacerFuncFromMap(map[string]string{})
+
+	// Parsed defaults are valid choices
+	tmpl, err := ParseString("{{.g:Hi|Hello|@Hey}}")
+	require.NoError(t, err)
+	got, err := tmpl.Execute(empty)
+	require.NoError(t, err)
+	assert.Equal(t, "Hey", got)
+
+	// A hand-built node whose default is not a choice is rejected like any other value
+	tmpl = &Template{Nodes: []Node{&VarNode{Key: "g", Choices: []string{"Hi", "Hello"}, Default: "Hey", HasDef: true}}}
+	_, err = tmpl.Execute(empty)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	assert.Contains(t, err.Error(), `"Hey"`)
+
+	tmpl.Nodes[0].(*VarNode).Default = "Hello"
+	got, err = tmpl.Execute(empty)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", got)
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	src := "feat: {{.desc}}\n\nSee {{.ticket:/^[A-Z]+$/}} and {{name}} for details"
+
+	_, err := ParseString(src)
+	require.ErrorIs(t, err, ErrUnknownFunction)
+
+	var perr *ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, strings.Index(src, "{{name}}"), perr.Offset)
+	assert.Equal(t, 3, perr.Line)
+	assert.Equal(t, 32, perr.Column)
+	assert.True(t, strings.HasPrefix(err.Error(), "3:32: "))
+
+	// ParseStream reports the same position
+	_, err = ParseStream(strings.NewReader(src))
+	var streamErr *ParseError
+	require.ErrorAs(t, err, &streamErr)
+	assert.Equal(t, *perr, *streamErr)
+}
+
+func TestParseErrorFirstLine(t *testing.T) {
+	_, err := ParseString("{{.x:len=5..1}}")
+
+	var perr *ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 0, perr.Offset)
+	assert.Equal(t, 1, perr.Line)
+	assert.Equal(t, 1, perr.Column)
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestOptionalVariable(t *testing.T) {
+	tmpl, err := ParseString("feat({{.scope?}}): {{.desc}}{{.issue?|capitalize: /^#\\d+$/}}")
+	require.NoError(t, err)
+
+	vars := tmpl.Variables()
+	require.Len(t, vars, 3)
+	assert.Equal(t, "scope", vars[0].Key)
+	assert.True(t, vars[0].Optional)
+	assert.False(t, vars[1].Optional)
+	assert.Equal(t, "issue", vars[2].Key)
+	assert.Equal(t, []string{"capitalize"}, vars[2].Filters)
+
+	// Absent optional variables render empty without being validated
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"desc": "add login"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(): add login", got)
+
+	// Present ones are rendered and validated as usual
+	got, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": "auth", "desc": "add login", "issue": "#12"}))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(auth): add login#12", got)
+
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"desc": "add login", "issue": "12"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	// Non-optional variables are still required
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": "auth"}))
+	require.ErrorIs(t, err, ErrNoReplacement)
+	assert.Contains(t, err.Error(), `"desc"`)
+
+	// Strict replacers still require them
+	_, err = tmpl.Execute(StrictMapReplacer(map[string]string{"desc": "add login"}))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+}
+
+func TestClone(t *testing.T) {
+	tmpl, err := ParseString(`{{.type:feat|@fix}}: {{.desc|capitalize}} {{date "2006"}}`)
+	require.NoError(t, err)
+
+	clone := tmpl.Clone()
+	require.Len(t, clone.Nodes, len(tmpl.Nodes))
+
+	v := clone.Variables()[0]
+	v.Default = "feat"
+	v.Choices[0] = "docs"
+	v.Choices = append(v.Choices, "chore")
+	clone.Variables()[1].Filters[0] = "upper"
+	clone.Nodes[1].(*TextNode).Text = " - "
+	clone.Nodes[4].(*FuncNode).Args[0] = "01"
+
+	orig := tmpl.Variables()[0]
+	assert.Equal(t, "fix", orig.Default)
+	assert.Equal(t, []string{"feat", "fix"}, orig.Choices)
+	assert.Equal(t, []string{"capitalize"}, tmpl.Variables()[1].Filters)
+	assert.Equal(t, ": ", tmpl.Nodes[1].(*TextNode).Text)
+	assert.Equal(t, []string{"2006"}, tmpl.Nodes[4].(*FuncNode).Args)
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"Plain variables", "Hello {{.name}}!", "Hello {{.name}}!"},
+		{"Default first", "{{ .type: @fix | feat }}", "{{.type:@fix|feat}}"},
+		{"Repeated default", "{{.type:feat|fix|@fix}}", "{{.type:feat|@fix}}"},
+		{"Default alongside", "{{.type:feat|@docs}}", "{{.type:feat|@docs}}"},
+		{"Lone default", "{{.scope:@}}", "{{.scope:@}}"},
+		{"Filters and optional", "{{.desc? | capitalize}}", "{{.desc?|capitalize}}"},
+		{"Pattern", `{{.ticket:/^[A-Z]+-\d+$/}}`, `{{.ticket:/^[A-Z]+-\d+$/}}`},
+		{"Length", "{{.desc:len=..72}}", "{{.desc:len=..72}}"},
+		{"Function", `{{date "Jan 2"}} {{- now}}`, `{{date "Jan 2"}}{{now}}`},
+		{"Unclosed marker", "Hello {{.name", "Hello {{.name"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString(tc.src)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, tmpl.String())
+
+			reparsed, err := ParseString(tmpl.String())
+			require.NoError(t, err)
+			assert.Equal(t, tmpl.Nodes, reparsed.Nodes)
+		})
+	}
+}
+
+func TestExecuteWithHook(t *testing.T) {
+	tmpl, err := ParseString("{{.type:@feat}}: {{.desc}} {{.issue}}")
+	require.NoError(t, err)
+
+	type call struct {
+		node     Node
+		rendered string
+		err      error
+	}
+	var calls []call
+	hook := func(node Node, rendered string, err error) {
+		calls = append(calls, call{node, rendered, err})
+	}
+
+	got, err := tmpl.ExecuteWithHook(ReplacerFuncFromMap(map[string]string{"desc": "add login", "issue": "#1"}), hook)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add login #1", got)
+
+	require.Len(t, calls, len(tmpl.Nodes))
+	var rendered []string
+	for i, c := range calls {
+		assert.Same(t, tmpl.Nodes[i], c.node)
+		assert.NoError(t, c.err)
+		rendered = append(rendered, c.rendered)
+	}
+	assert.Equal(t, []string{"feat", ": ", "add login", " ", "#1"}, rendered)
+
+	// The failing node is reported and rendering stops there
+	calls = nil
+	_, err = tmpl.ExecuteWithHook(ReplacerFuncFromMap(nil), hook)
+	require.ErrorIs(t, err, ErrNoReplacement)
+	require.Len(t, calls, 3)
+	assert.Same(t, tmpl.Nodes[2], calls[2].node)
+	assert.ErrorIs(t, calls[2].err, ErrNoReplacement)
+}
+
+func TestQuotedChoices(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		wantDefault string
+		wantChoices []string
+	}{
+		{
+			name:        "Default with delimiters",
+			template:    `{{.url:@"https://example.com/a:b|c"}}`,
+			wantDefault: "https://example.com/a:b|c",
+			wantChoices: []string{},
+		},
+		{
+			name:        "Choices with delimiters",
+			template:    `{{.sep:"a|b"|":"|@"x \"y\""}}`,
+			wantDefault: `x "y"`,
+			wantChoices: []string{"a|b", ":", `x "y"`},
+		},
+		{
+			name:        "Quoted and plain choices",
+			template:    `{{.type: feat | "fix|hot" | @docs }}`,
+			wantDefault: "docs",
+			wantChoices: []string{"feat", "fix|hot", "docs"},
+		},
+		{
+			name:        "Escaped quote and leading space",
+			template:    `{{.x:@" \\ |"}}`,
+			wantDefault: ` \ |`,
+			wantChoices: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseString(tc.template)
+			require.NoError(t, err)
+			v := tmpl.Variables()[0]
+			assert.True(t, v.HasDef)
+			assert.Equal(t, tc.wantDefault, v.Default)
+			assert.Equal(t, tc.wantChoices, v.Choices)
+
+			got, err := tmpl.Execute(ReplacerFuncFromMap(nil))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantDefault, got)
+
+			// String quotes them back
+			reparsed, err := ParseString(tmpl.String())
+			require.NoError(t, err)
+			assert.Equal(t, tmpl.Nodes, reparsed.Nodes)
+		})
+	}
+}
+
+func TestQuotedChoiceErrors(t *testing.T) {
+	for _, src := range []string{`{{.x:@"unterminated}}`, `{{.x:"a"b|c}}`} {
+		_, err := ParseString(src)
+		assert.ErrorIs(t, err, ErrInvalidTokenSyntax, src)
+	}
+}
+
+func TestComputedDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   map[string]string
+		expected string
+	}{
+		{
+			name:     "references a present variable",
+			template: "{{.title:@{{.type}}: changes}}",
+			values:   map[string]string{"type": "fix"},
+			expected: "fix: changes",
+		},
+		{
+			name:     "provided value wins",
+			template: "{{.title:@{{.type}}: changes}}",
+			values:   map[string]string{"type": "fix", "title": "custom"},
+			expected: "custom",
+		},
+		{
+			name:     "chain of two references",
+			template: "{{.title:@{{.summary:@{{.type}} work}} done}}",
+			values:   map[string]string{"type": "docs"},
+			expected: "docs work done",
+		},
+		{
+			name:     "alongside choices",
+			template: "{{.kind:feat|fix|@{{.type}}}}",
+			values:   map[string]string{"type": "fix"},
+			expected: "fix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseString(tt.template)
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(ReplacerFuncFromMap(tt.values))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+
+			streamed, err := parseStream(bufio.NewReaderSize(iotest.OneByteReader(strings.NewReader(tt.template)), 16))
+			require.NoError(t, err)
+			assert.Equal(t, tmpl, streamed)
+		})
+	}
+}
+
+func TestStrayOpeningMarker(t *testing.T) {
+	// An opening marker outside a default does not nest, so it does not hide the tokens after it
+	for _, src := range []string{"Use {{ to open: {{.x}}", "a {{ b {{.x}} c {{.y}}"} {
+		_, err := ParseString(src)
+		assert.ErrorIs(t, err, ErrInvalidTokenSyntax, src)
+
+		_, err = ParseStream(strings.NewReader(src))
+		assert.ErrorIs(t, err, ErrInvalidTokenSyntax, src)
+	}
+
+	// After a default the next token is found as usual
+	tmpl, err := ParseString("{{.x:@a}} and {{.y}}")
+	require.NoError(t, err)
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"y": "b"}))
+	require.NoError(t, err)
+	assert.Equal(t, "a and b", got)
+}
+
+func TestComputedDefaultErrors(t *testing.T) {
+	tmpl, err := ParseString("{{.a:@{{.a}}}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(nil))
+	require.ErrorIs(t, err, ErrDefaultCycle)
+	assert.ErrorContains(t, err, "a -> a")
+
+	tmpl, err = ParseString("{{.title:@{{.type}}: changes}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(nil))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+
+	tmpl, err = ParseString("{{.kind:feat|@{{.type}}}}")
+	require.NoError(t, err)
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "docs"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestComputedDefaultRoundTrip(t *testing.T) {
+	tmpl, err := ParseString("{{.title:@{{.type}}: changes}} and {{.x:@{{.y}} z}}")
+	require.NoError(t, err)
+
+	again, err := ParseString(tmpl.String())
+	require.NoError(t, err)
+	got, err := again.Execute(ReplacerFuncFromMap(map[string]string{"type": "fix", "y": "w"}))
+	require.NoError(t, err)
+	assert.Equal(t, "fix: changes and w z", got)
+}
+
+func TestRenderDefault(t *testing.T) {
+	tmpl, err := ParseString("{{.type:@feat}} {{.title:@{{.type}} stuff}} {{.desc}}")
+	require.NoError(t, err)
+	vars := tmpl.Variables()
+
+	got, err := vars[0].RenderDefault(ReplacerFuncFromMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "feat", got)
+
+	got, err = vars[1].RenderDefault(ReplacerFuncFromMap(map[string]string{"type": "fix"}))
+	require.NoError(t, err)
+	assert.Equal(t, "fix stuff", got)
+
+	// Like when rendering, the default of another use of a key does not apply
+	_, err = vars[1].RenderDefault(ReplacerFuncFromMap(nil))
+	assert.ErrorIs(t, err, ErrNoReplacement)
+
+	got, err = vars[2].RenderDefault(ReplacerFuncFromMap(nil))
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestPlainTextTemplate(t *testing.T) {
+	tmpl, err := ParseString("no variables here\n")
+	require.NoError(t, err)
+	assert.Equal(t, []Node{&TextNode{Text: "no variables here\n"}}, tmpl.Nodes)
+
+	got, err := tmpl.Execute(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "no variables here\n", got)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.ExecuteTo(&buf, nil))
+	assert.Equal(t, "no variables here\n", buf.String())
+}
+
+func TestExecuteEach(t *testing.T) {
+	tmpl, err := ParseString("{{.type:@feat}}: {{.desc}}")
+	require.NoError(t, err)
+
+	messages, errs := tmpl.ExecuteEach([]Replacer{
+		ReplacerFuncFromMap(map[string]string{"desc": "add login"}),
+		ReplacerFuncFromMap(map[string]string{"type": "fix"}),
+		ReplacerFuncFromMap(map[string]string{"type": "docs", "desc": "fix typo"}),
+	})
+	assert.Equal(t, []string{"feat: add login", "", "docs: fix typo"}, messages)
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrNoReplacement)
+	assert.NoError(t, errs[2])
+
+	messages, errs = tmpl.ExecuteEach([]Replacer{ReplacerFuncFromMap(map[string]string{"desc": "x"})})
+	assert.Equal(t, []string{"feat: x"}, messages)
+	assert.Nil(t, errs)
+}
+
+func TestVariableDescription(t *testing.T) {
+	tests := []struct {
+		template string
+		want     VarNode
+	}{
+		{template: "{{.scope#desc=the affected module}}", want: VarNode{Key: "scope", Choices: []string{}, Desc: "the affected module"}},
+		{template: "{{.scope?#desc= the affected module }}", want: VarNode{Key: "scope", Choices: []string{}, Optional: true, Desc: "the affected module"}},
+		{
+			template: "{{.type:feat|@fix#desc=kind of change: feat or fix}}",
+			want:     VarNode{Key: "type", Choices: []string{"feat", "fix"}, Default: "fix", HasDef: true, Desc: "kind of change: feat or fix"},
+		},
+		{template: `{{.tag:@"a#desc=b"}}`, want: VarNode{Key: "tag", Choices: []string{}, Default: "a#desc=b", HasDef: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tmpl, err := ParseString(tt.template)
+			require.NoError(t, err)
+			require.Len(t, tmpl.Nodes, 1)
+			assert.Equal(t, &tt.want, tmpl.Nodes[0])
+
+			// The description survives a round trip and does not render
+			again, err := ParseString(tmpl.String())
+			require.NoError(t, err)
+			assert.Equal(t, tmpl.Nodes, again.Nodes)
+		})
+	}
+
+	tmpl, err := ParseString("{{.scope#desc=the affected module}}")
+	require.NoError(t, err)
+	got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"scope": "api"}))
+	require.NoError(t, err)
+	assert.Equal(t, "api", got)
+}
+
+func TestChoiceAliases(t *testing.T) {
+	tmpl, err := ParseString("{{.type:feat=f|fix = x|@docs=d}}: {{.desc}}")
+	require.NoError(t, err)
+	v := tmpl.Nodes[0].(*VarNode)
+	assert.Equal(t, []string{"feat", "fix", "docs"}, v.Choices)
+	assert.Equal(t, map[string]string{"f": "feat", "x": "fix", "d": "docs"}, v.Aliases)
+	assert.Equal(t, "docs", v.Default)
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "f", want: "feat: y"},
+		{value: "feat", want: "feat: y"},
+		{value: "x", want: "fix: y"},
+		{value: "d", want: "docs: y"},
+	}
+	for _, tt := range tests {
+		got, err := tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": tt.value, "desc": "y"}))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err = tmpl.Execute(ReplacerFuncFromMap(map[string]string{"type": "z", "desc": "y"}))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	// Aliases survive a round trip
+	again, err := ParseString(tmpl.String())
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.Nodes, again.Nodes)
+
+	// as do several aliases of one choice, the choice being repeated for each
+	tmpl, err = ParseString("{{.type:feat=ft|feat=f|fix=x|@docs=d|docs=dd}}")
+	require.NoError(t, err)
+	assert.Equal(t, "{{.type:feat=f|feat=ft|fix=x|@docs=d|docs=dd}}", tmpl.String())
+	again, err = ParseString(tmpl.String())
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.Nodes, again.Nodes)
+
+	// Quoted choices keep their equals sign
+	tmpl, err = ParseString(`{{.op:"a=b"|c}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a=b", "c"}, tmpl.Nodes[0].(*VarNode).Choices)
+	assert.Nil(t, tmpl.Nodes[0].(*VarNode).Aliases)
+
+	for _, src := range []string{"{{.type:feat=|fix}}", "{{.type:feat=f|fix=f}}"} {
+		_, err := ParseString(src)
+		assert.ErrorIs(t, err, ErrInvalidTokenSyntax, src)
+	}
+}
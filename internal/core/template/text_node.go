@@ -10,3 +10,8 @@ func (t *TextNode) WriteTo(w io.Writer, _ Replacer) error {
 	_, err := io.WriteString(w, t.Text)
 	return err
 }
+
+// String returns the text itself.
+func (t *TextNode) String() string {
+	return t.Text
+}
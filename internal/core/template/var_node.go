@@ -2,43 +2,228 @@ package template
 
 import (
 	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // VarNode holds a placeholder with optional choices and default.
 type VarNode struct {
-	Key     string
+	Key string
+	// Choices lists the accepted values; empty means any value.
+	// Parsed nodes include the default among their choices unless it is computed.
 	Choices []string
 	Default string
-	HasDef  bool
+	// DefaultNodes, when set, compute the default from other variables
+	// at render time; Default then holds their source.
+	DefaultNodes []Node
+	HasDef       bool
+	// Aliases map shorthand values to the choices they stand for, e.g. f to feat.
+	Aliases map[string]string
+	// Pattern, when set, must match the value for it to be accepted.
+	Pattern *regexp.Regexp
+	// MinLen and MaxLen bound the value length in runes; 0 means unbounded.
+	MinLen int
+	MaxLen int
+	// Filters name the filters applied in order to the value once validated.
+	Filters []string
+	// Optional renders a missing value as empty instead of failing.
+	// Unlike a default it provides no value, so nothing is validated or filtered.
+	Optional bool
+	// Desc describes the expected value to whoever fills it in; it does not affect rendering.
+	Desc string
 }
 
 // WriteTo writes the rendered node to w, using replacements.
 func (v *VarNode) WriteTo(w io.Writer, r Replacer) error {
+	r, resolving := unwrapDefaults(r)
 	val, found := r.Get(v.Key)
+	if found && val == "" && v.HasDef && usesDefaultOnEmpty(r) {
+		found = false
+	}
 	if !found {
-		if v.HasDef {
+		if slices.Contains(resolving, v.Key) {
+			return NewDefaultCycleError(append(resolving, v.Key))
+		}
+		switch strict := isStrict(r); {
+		case v.HasDef && v.DefaultNodes != nil && !strict:
+			var err error
+			if val, err = v.computeDefault(r, resolving); err != nil {
+				return err
+			}
+		case v.HasDef && !strict:
 			val = v.Default
-		} else {
+		case v.Optional && !strict:
+			return nil
+		default:
+			if p, ok := r.(placeholderReplacer); ok {
+				_, err := io.WriteString(w, p.placeholder(v.Key))
+				return err
+			}
 			return NewNoReplacementError(v.Key)
 		}
 	}
 
-	if len(v.Choices) > 0 && found && !(v.HasDef && len(v.Choices) == 1) {
+	// Defaults are checked too: parsed nodes always list them among the
+	// choices, so only hand-built nodes can fail here
+	if len(v.Choices) > 0 {
 		if !v.isValidChoice(val) {
 			return NewInvalidValueError(val, v.Key, v.Choices)
 		}
+		val = v.resolveAlias(val)
+	}
+
+	if v.Pattern != nil && !v.Pattern.MatchString(val) {
+		return NewPatternMismatchError(val, v.Key, v.Pattern)
+	}
+
+	if n := utf8.RuneCountInString(val); n < v.MinLen || (v.MaxLen > 0 && n > v.MaxLen) {
+		return NewLengthMismatchError(val, v.Key, v.MinLen, v.MaxLen)
+	}
+
+	for _, name := range v.Filters {
+		filter, ok := filters[name]
+		if !ok {
+			return NewUnknownFilterError(name)
+		}
+		val = filter(val)
 	}
 
 	_, err := io.WriteString(w, val)
 	return err
 }
 
-// isValidChoice checks if the given value is a valid choice.
+// defaultsReplacer carries the keys whose computed defaults are being rendered
+// through the nodes of those defaults, to detect defaults depending on themselves.
+type defaultsReplacer struct {
+	Replacer
+	resolving []string
+}
+
+// unwrapDefaults returns the replacer wrapped by a defaultsReplacer and the keys being resolved
+func unwrapDefaults(r Replacer) (Replacer, []string) {
+	if d, ok := r.(defaultsReplacer); ok {
+		return d.Replacer, d.resolving
+	}
+	return r, nil
+}
+
+// RenderDefault returns the default of v as rendering it with r would use it:
+// a computed default is rendered from the values of r, a plain one is returned as is.
+// A variable without a default has an empty one.
+func (v *VarNode) RenderDefault(r Replacer) (string, error) {
+	if v.DefaultNodes == nil {
+		return v.Default, nil
+	}
+	r, resolving := unwrapDefaults(r)
+	return v.computeDefault(r, resolving)
+}
+
+// computeDefault renders the default nodes with r
+func (v *VarNode) computeDefault(r Replacer, resolving []string) (string, error) {
+	scoped := defaultsReplacer{Replacer: r, resolving: append(slices.Clip(resolving), v.Key)}
+
+	var b strings.Builder
+	for _, node := range v.DefaultNodes {
+		if err := node.WriteTo(&b, scoped); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// String returns the token source of the node in canonical form:
+// the key, the optional mark, the filters and then a single constraint.
+// Choices keep their order with the default marked in place.
+func (v *VarNode) String() string {
+	var b strings.Builder
+	b.WriteString(openMarker + varPrefix + v.Key)
+	if v.Optional {
+		b.WriteString(optionalMark)
+	}
+	for _, name := range v.Filters {
+		b.WriteString(filterSep + name)
+	}
+
+	switch {
+	case v.Pattern != nil:
+		b.WriteString(choiceSep + patternMark + v.Pattern.String() + patternMark)
+	case v.MinLen > 0 || v.MaxLen > 0:
+		b.WriteString(choiceSep + lenPrefix + lengthRange(v.MinLen, v.MaxLen))
+	case len(v.Choices) > 0 || v.HasDef:
+		parts := make([]string, 0, len(v.Choices)+1)
+		marked := false
+		for _, c := range v.Choices {
+			prefix := ""
+			if v.HasDef && !marked && c == v.Default {
+				prefix = defPrefix
+				marked = true
+			}
+			parts = append(parts, v.choiceParts(prefix, c)...)
+		}
+		if v.HasDef && !marked {
+			parts = append(parts, v.choiceParts(defPrefix, v.Default)...)
+		}
+		b.WriteString(choiceSep + strings.Join(parts, choiceDelim))
+	}
+
+	if v.Desc != "" {
+		b.WriteString(descMark + v.Desc)
+	}
+	b.WriteString(closeMarker)
+	return b.String()
+}
+
+// choiceParts returns choice c as written in a token, with prefix marking it.
+// The syntax allows a single alias per choice, so c is repeated for each of its
+// aliases in sorted order; only the first part carries prefix.
+func (v *VarNode) choiceParts(prefix, c string) []string {
+	var names []string
+	for alias, choice := range v.Aliases {
+		if choice == c {
+			names = append(names, alias)
+		}
+	}
+	if len(names) == 0 {
+		return []string{prefix + quoteChoice(c)}
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = quoteChoice(c) + aliasMark + quoteChoice(name)
+	}
+	parts[0] = prefix + parts[0]
+	return parts
+}
+
+// quoteChoice quotes a choice that would otherwise not parse back as itself
+func quoteChoice(c string) string {
+	if strings.ContainsAny(c, choiceDelim+choiceSep+quoteMark+aliasMark+`\`) || c != strings.TrimSpace(c) ||
+		strings.Contains(c, descMark) || strings.HasPrefix(c, defPrefix) || strings.HasPrefix(c, patternMark) || strings.HasPrefix(c, lenPrefix) {
+		return strconv.Quote(c)
+	}
+	return c
+}
+
+// isValidChoice checks if the given value is a valid choice or an alias of one.
 func (v *VarNode) isValidChoice(val string) bool {
 	for _, c := range v.Choices {
 		if c == val {
 			return true
 		}
 	}
-	return false
+	_, ok := v.Aliases[val]
+	return ok
+}
+
+// resolveAlias returns the choice val stands for, or val itself if it is not an alias.
+// Choices win over aliases of the same name.
+func (v *VarNode) resolveAlias(val string) string {
+	if c, ok := v.Aliases[val]; ok && !slices.Contains(v.Choices, val) {
+		return c
+	}
+	return val
 }
@@ -0,0 +1,45 @@
+// Package tcommit renders tcommit commit message templates from other Go programs.
+// It wraps the template engine used by the tcommit command behind a single call.
+package tcommit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/WhiCu/TCommit/internal/core/template"
+)
+
+// Errors returned by Render, matched with errors.Is
+var (
+	// ErrParse is returned for a template with invalid syntax
+	ErrParse = errors.New("invalid template")
+	// ErrMissingValue is returned for a variable given neither a value nor a default
+	ErrMissingValue = errors.New("missing value")
+	// ErrInvalidValue is returned for a value rejected by the constraints of its variable
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// Render parses templateSrc and fills its variables from replacements.
+// Variables missing from replacements fall back to their template defaults.
+//
+// Example:
+//
+//	msg, err := tcommit.Render("{{.type:feat|@fix}}: {{.desc}}", map[string]string{"desc": "add login"})
+//	// msg == "fix: add login"
+func Render(templateSrc string, replacements map[string]string) (string, error) {
+	tmpl, err := template.ParseString(templateSrc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrParse, err)
+	}
+
+	message, err := tmpl.Execute(template.ReplacerFuncFromMap(replacements))
+	switch {
+	case errors.Is(err, template.ErrNoReplacement):
+		return "", fmt.Errorf("%w: %w", ErrMissingValue, err)
+	case errors.Is(err, template.ErrInvalidValue):
+		return "", fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	case err != nil:
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return message, nil
+}
@@ -0,0 +1,41 @@
+package tcommit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	msg, err := Render("{{.type:feat|@fix}}({{.scope}}): {{.desc}}", map[string]string{"scope": "auth", "desc": "add login"})
+	require.NoError(t, err)
+	assert.Equal(t, "fix(auth): add login", msg)
+
+	// A nil map is fine for templates without required values
+	msg, err = Render("chore: {{.desc:@bump deps}}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "chore: bump deps", msg)
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		values   map[string]string
+		want     error
+		contains string
+	}{
+		{name: "parse error", src: "feat: {{.desc:len=x}}", want: ErrParse, contains: `invalid template: 1:7: invalid length constraint "x"`},
+		{name: "missing key", src: "feat: {{.desc}}", want: ErrMissingValue, contains: `missing value: no replacement for key "desc"`},
+		{name: "invalid value", src: "{{.type:feat|fix}}: x", values: map[string]string{"type": "wip"}, want: ErrInvalidValue, contains: `"wip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.src, tt.values)
+			require.ErrorIs(t, err, tt.want)
+			assert.ErrorContains(t, err, tt.contains)
+		})
+	}
+}